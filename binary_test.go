@@ -0,0 +1,151 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/macaron.v1"
+)
+
+var binaryTestCases = []binaryTestCase{
+	{
+		description:   "Happy path",
+		shouldSucceed: true,
+		payload:       map[string]interface{}{"title": "Glorious Post Title", "content": "Lorem ipsum dolor sit amet"},
+		expected:      Post{Title: "Glorious Post Title", Content: "Lorem ipsum dolor sit amet"},
+	},
+	{
+		description:   "Required field missing",
+		shouldSucceed: false,
+		payload:       map[string]interface{}{"content": "Lorem ipsum dolor sit amet"},
+		expected:      Post{Content: "Lorem ipsum dolor sit amet"},
+	},
+	{
+		description:   "Nested and embedded struct",
+		shouldSucceed: true,
+		payload: map[string]interface{}{
+			"title":   "Glorious Post Title",
+			"Id":      1,
+			"ratings": []int{4, 3},
+			"author":  map[string]interface{}{"name": "Matt Holt"},
+		},
+		expected: BlogPost{Post: Post{Title: "Glorious Post Title"}, Id: 1, Ratings: []int{4, 3}, Author: Person{Name: "Matt Holt"}},
+	},
+	{
+		description:   "Slice of structs exceeding max size",
+		shouldSucceed: false,
+		payload: map[string]interface{}{
+			"name":   "group1",
+			"people": []map[string]interface{}{{"name": "awoods"}, {"name": "anthony"}},
+		},
+		expected: Group{Name: "group1", People: []Person{{Name: "awoods"}, {Name: "anthony"}}},
+	},
+	{
+		description:   "Malformed payload",
+		shouldSucceed: false,
+		payload:       "-malformed-",
+		expected:      Post{},
+	},
+}
+
+func Test_Msgpack(t *testing.T) {
+	Convey("Test MessagePack", t, func() {
+		for _, testCase := range binaryTestCases {
+			Convey(testCase.description, func() {
+				performBinaryTest(Msgpack, msgpack.Marshal, _MSGPACK_CONTENT_TYPE, testCase)
+				performBinaryTest(BindIgnErr, msgpack.Marshal, _MSGPACK_CONTENT_TYPE, testCase)
+			})
+		}
+	})
+}
+
+func Test_Cbor(t *testing.T) {
+	Convey("Test CBOR", t, func() {
+		for _, testCase := range binaryTestCases {
+			Convey(testCase.description, func() {
+				performBinaryTest(Cbor, cbor.Marshal, _CBOR_CONTENT_TYPE, testCase)
+				performBinaryTest(BindIgnErr, cbor.Marshal, _CBOR_CONTENT_TYPE, testCase)
+			})
+		}
+	})
+}
+
+func performBinaryTest(binder handlerFunc, marshal func(interface{}) ([]byte, error), contentType string, testCase binaryTestCase) {
+	m := macaron.Classic()
+
+	binaryTestHandler := func(actual interface{}, errs Errors) {
+		if testCase.shouldSucceed {
+			So(errs, ShouldHaveLength, 0)
+		} else {
+			So(errs, ShouldNotBeEmpty)
+		}
+		So(actual, ShouldResemble, testCase.expected)
+	}
+
+	switch testCase.expected.(type) {
+	case Post:
+		m.Post(testRoute, binder(Post{}), func(actual Post, errs Errors) {
+			binaryTestHandler(actual, errs)
+		})
+	case BlogPost:
+		m.Post(testRoute, binder(BlogPost{}), func(actual BlogPost, errs Errors) {
+			binaryTestHandler(actual, errs)
+		})
+	case Group:
+		m.Post(testRoute, binder(groupWithLimit{}), func(actual groupWithLimit, errs Errors) {
+			binaryTestHandler(Group(actual), errs)
+		})
+	}
+
+	var payload []byte
+	if testCase.payload == "-malformed-" {
+		payload = []byte{0xc1, 0xff, 0x00}
+	} else {
+		var err error
+		payload, err = marshal(testCase.payload)
+		So(err, ShouldBeNil)
+	}
+
+	req, err := http.NewRequest("POST", testRoute, bytes.NewReader(payload))
+	So(err, ShouldBeNil)
+	req.Header.Set("Content-Type", contentType)
+
+	resp := httptest.NewRecorder()
+	m.ServeHTTP(resp, req)
+	So(resp.Code, ShouldEqual, http.StatusOK)
+}
+
+type (
+	binaryTestCase struct {
+		description   string
+		shouldSucceed bool
+		payload       interface{}
+		expected      interface{}
+	}
+
+	// groupWithLimit has the same layout as Group with a tighter limit,
+	// so slice validation can be exercised regardless of the wire format.
+	groupWithLimit struct {
+		Name   string   `json:"name" binding:"Required"`
+		People []Person `json:"people" binding:"MaxSize(1)"`
+	}
+)
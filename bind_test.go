@@ -41,6 +41,12 @@ func Test_Bind(t *testing.T) {
 			}
 		})
 
+		Convey("Bind XML", func() {
+			for _, testCase := range xmlTestCases {
+				performXmlTest(t, Bind, testCase)
+			}
+		})
+
 		Convey("Bind multipart form", func() {
 			for _, testCase := range multipartFormTestCases {
 				performMultipartFormTest(t, Bind, testCase)
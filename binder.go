@@ -0,0 +1,267 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"reflect"
+	"sync"
+
+	"gopkg.in/macaron.v1"
+)
+
+type (
+	// Converter converts a form, query, URL parameter or header value
+	// into a value of the type it is registered for.
+	Converter func(string) (interface{}, error)
+
+	// YamlLimits caps what a YAML payload may hold, a zero value meaning
+	// no limit. Payloads beyond a limit are reported as ERR_LIMIT_EXCEEDED.
+	YamlLimits struct {
+		// MaxBytes caps the size of the document.
+		MaxBytes int64
+		// MaxAliases caps the number of aliases the document expands to,
+		// those within anchored values included.
+		MaxAliases int
+		// MaxDepth caps how deep mappings and sequences are nested, those
+		// that aliases expand to included if MaxAliases is set.
+		MaxDepth int
+	}
+
+	// Binder holds settings for the binding middleware, on top of the
+	// package-wide ones. It takes effect for the requests it is mapped to
+	// with its Handler, e.g. for a whole application with m.Use(b.Handler())
+	// or for a single route with m.Post("/", b.Handler(), Bind(Post{}), ...).
+	Binder struct {
+		lock            sync.RWMutex
+		converters      map[reflect.Type]Converter
+		profile         *ParseProfile
+		strictJson      bool
+		strictYaml      bool
+		yamlLimits      YamlLimits
+		maxBody         int64
+		maxDecompressed int64
+	}
+)
+
+var (
+	convertersLock sync.RWMutex
+	converters     = map[reflect.Type]Converter{}
+
+	profilesLock sync.RWMutex
+	profiles     = map[string]*ParseProfile{
+		"strict": {},
+		"lenient": {
+			TrimSpace:      true,
+			GroupSeparator: ",",
+			Booleans:       lenientBooleans,
+			FoldCase:       true,
+		},
+		"de": {
+			TrimSpace:        true,
+			DecimalSeparator: ",",
+			GroupSeparator:   ".",
+			Booleans:         germanBooleans,
+			FoldCase:         true,
+		},
+	}
+
+	// defaultBinder is used for requests no Binder is mapped to.
+	defaultBinder = NewBinder()
+)
+
+// NewBinder returns a Binder with no settings of its own.
+func NewBinder() *Binder {
+	return &Binder{
+		converters: map[reflect.Type]Converter{},
+	}
+}
+
+// Handler returns middleware that maps the Binder to the context, so that
+// the binding middleware handling the request later on use its settings,
+// and limits the request body as set with SetMaxBodyBytes.
+func (b *Binder) Handler() macaron.Handler {
+	return func(ctx *macaron.Context) {
+		ctx.Map(b)
+		limitBody(ctx, b.getMaxBodyBytes())
+	}
+}
+
+// binderOf returns the Binder mapped to the context, or the default one.
+func binderOf(ctx *macaron.Context) *Binder {
+	if v := ctx.GetVal(reflect.TypeOf((*Binder)(nil))); v.IsValid() {
+		return v.Interface().(*Binder)
+	}
+	return defaultBinder
+}
+
+// RegisterConverter makes form, query, URL parameter and header values
+// of the given type be converted by fn, for every request. The value it
+// returns must be assignable to the type; an error it returns is reported
+// as ERR_CONVERSION with its message.
+func RegisterConverter(typ reflect.Type, fn Converter) {
+	convertersLock.Lock()
+	defer convertersLock.Unlock()
+	converters[typ] = fn
+}
+
+// RegisterConverter works like the package-level RegisterConverter, but
+// only for the requests the Binder is mapped to, and takes precedence.
+func (b *Binder) RegisterConverter(typ reflect.Type, fn Converter) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.converters[typ] = fn
+}
+
+// converter returns the converter registered for the type, if any.
+func (b *Binder) converter(typ reflect.Type) Converter {
+	b.lock.RLock()
+	fn := b.converters[typ]
+	b.lock.RUnlock()
+	if fn != nil {
+		return fn
+	}
+
+	convertersLock.RLock()
+	defer convertersLock.RUnlock()
+	return converters[typ]
+}
+
+// RegisterProfile makes a parsing profile available by name, for Binders
+// to use with UseProfile and for fields to use with their form_profile tag.
+// The built-in profiles are "strict", "lenient" and "de". Profiles named
+// by form_profile tags must be registered before the middleware is built.
+func RegisterProfile(name string, p ParseProfile) {
+	profilesLock.Lock()
+	defer profilesLock.Unlock()
+	profiles[name] = &p
+}
+
+// lookupProfile returns the parsing profile registered by the name.
+func lookupProfile(name string) *ParseProfile {
+	profilesLock.RLock()
+	defer profilesLock.RUnlock()
+	p, ok := profiles[name]
+	if !ok {
+		panic("binding: unknown profile " + name)
+	}
+	return p
+}
+
+// UseProfile makes the Binder parse numbers and booleans by the parsing
+// profile registered by the name, unless a field asks for another one
+// with its form_profile tag.
+func (b *Binder) UseProfile(name string) {
+	p := lookupProfile(name)
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.profile = p
+}
+
+// profileOf returns the parsing profile a field with the tag is parsed by.
+func (b *Binder) profileOf(tag reflect.StructTag) *ParseProfile {
+	if name, ok := tag.Lookup("form_profile"); ok {
+		return lookupProfile(name)
+	}
+
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	if b.profile != nil {
+		return b.profile
+	}
+	return defaultProfile
+}
+
+// SetStrictJson makes the Binder decode JSON payloads strictly or not.
+// A strict payload must hold exactly one value, and its objects must
+// neither repeat a key nor have one that matches no field of the struct
+// they are decoded into. Violations are reported as ERR_TRAILING_DATA,
+// ERR_DUPLICATE_KEY and ERR_UNKNOWN_FIELD, the latter two naming the key
+// by its path, e.g. "items[2].name". A model implementing StrictJsonModel
+// decides for itself.
+func (b *Binder) SetStrictJson(strict bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.strictJson = strict
+}
+
+func (b *Binder) isStrictJson() bool {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return b.strictJson
+}
+
+// SetStrictYaml makes the Binder decode YAML payloads strictly or not.
+// A strict payload must not have keys that match no field of the struct
+// they are decoded into; they are reported as ERR_UNKNOWN_FIELD, naming
+// the key by its path. A model implementing StrictYamlModel decides for
+// itself.
+func (b *Binder) SetStrictYaml(strict bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.strictYaml = strict
+}
+
+func (b *Binder) isStrictYaml() bool {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return b.strictYaml
+}
+
+// SetYamlLimits caps what the YAML payloads the Binder decodes may hold.
+func (b *Binder) SetYamlLimits(limits YamlLimits) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.yamlLimits = limits
+}
+
+func (b *Binder) getYamlLimits() YamlLimits {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return b.yamlLimits
+}
+
+// SetMaxBodyBytes limits the request bodies the Binder is mapped to to n
+// bytes, n <= 0 meaning no limit. A body beyond the limit is reported as
+// ERR_BODY_TOO_LARGE; see MaxBodyBytes to limit single routes instead.
+func (b *Binder) SetMaxBodyBytes(n int64) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.maxBody = n
+}
+
+func (b *Binder) getMaxBodyBytes() int64 {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return b.maxBody
+}
+
+// SetMaxDecompressedBytes limits the size the compressed request bodies
+// the Binder is mapped to may decompress to to n bytes, n < 0 meaning no
+// limit and n == 0 the package-wide MaxDecompressedBytes. A body beyond
+// the limit is reported as ERR_BODY_TOO_LARGE.
+func (b *Binder) SetMaxDecompressedBytes(n int64) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.maxDecompressed = n
+}
+
+func (b *Binder) getMaxDecompressedBytes() int64 {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	if b.maxDecompressed == 0 {
+		return MaxDecompressedBytes
+	}
+	return b.maxDecompressed
+}
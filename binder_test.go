@@ -0,0 +1,220 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/macaron.v1"
+)
+
+type (
+	// Stand-ins for third-party types that cannot be given methods.
+	geoPoint struct {
+		Lat, Lng float64
+	}
+	cents int64
+
+	placeForm struct {
+		Location geoPoint   `form:"loc"`
+		Stops    []geoPoint `form:"stop"`
+		Origin   geoPoint   `header:"X-Origin"`
+		Price    cents      `form:"price"`
+	}
+)
+
+func init() {
+	RegisterConverter(reflect.TypeOf(geoPoint{}), func(s string) (interface{}, error) {
+		var p geoPoint
+		if _, err := fmt.Sscanf(s, "%f,%f", &p.Lat, &p.Lng); err != nil {
+			return nil, errors.New("invalid geo point " + s)
+		}
+		return p, nil
+	})
+}
+
+func Test_RegisterConverter(t *testing.T) {
+	Convey("Register custom converters", t, func() {
+		b := NewBinder()
+		b.RegisterConverter(reflect.TypeOf(cents(0)), func(s string) (interface{}, error) {
+			var units, hundredths int64
+			if _, err := fmt.Sscanf(s, "%d.%d", &units, &hundredths); err != nil {
+				return nil, errors.New("invalid price " + s)
+			}
+			return cents(units*100 + hundredths), nil
+		})
+
+		performConverterTest := func(withBinder bool, query string, header http.Header, expected placeForm, expectedErrors Errors) {
+			check := func(actual placeForm, errs Errors) {
+				So(actual, ShouldResemble, expected)
+				if len(expectedErrors) == 0 {
+					So(errs, ShouldBeEmpty)
+				} else {
+					So(errs, ShouldResemble, expectedErrors)
+				}
+			}
+
+			m := macaron.Classic()
+			if withBinder {
+				m.Get(testRoute, b.Handler(), Form(placeForm{}), check)
+				m.Get("/header", b.Handler(), Header(placeForm{}), check)
+			} else {
+				m.Get(testRoute, Form(placeForm{}), check)
+				m.Get("/header", Header(placeForm{}), check)
+			}
+
+			path := testRoute + query
+			if header != nil {
+				path = "/header"
+			}
+			req, err := http.NewRequest("GET", path, nil)
+			So(err, ShouldBeNil)
+			for k, v := range header {
+				req.Header[k] = v
+			}
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+		}
+
+		Convey("Global converter", func() {
+			performConverterTest(false, "?loc=52.5,13.4&stop=1,2&stop=3,4", nil,
+				placeForm{
+					Location: geoPoint{52.5, 13.4},
+					Stops:    []geoPoint{{1, 2}, {3, 4}},
+				}, nil)
+			performConverterTest(false, "", http.Header{"X-Origin": {"48.1,11.6"}},
+				placeForm{Origin: geoPoint{48.1, 11.6}}, nil)
+		})
+
+		Convey("Per-binder converter", func() {
+			performConverterTest(true, "?loc=52.5,13.4&price=12.34", nil,
+				placeForm{Location: geoPoint{52.5, 13.4}, Price: 1234}, nil)
+			performConverterTest(false, "?price=12.34", nil,
+				placeForm{},
+				Errors{
+					{FieldNames: []string{"price"}, Classification: ERR_INTERGER_TYPE, Message: "Value could not be parsed as integer"},
+				})
+		})
+
+		Convey("Converter errors", func() {
+			performConverterTest(true, "?loc=north&price=free", nil,
+				placeForm{},
+				Errors{
+					{FieldNames: []string{"loc"}, Classification: ERR_CONVERSION, Message: "invalid geo point north"},
+					{FieldNames: []string{"price"}, Classification: ERR_CONVERSION, Message: "invalid price free"},
+				})
+		})
+	})
+}
+
+type priceForm struct {
+	Amount   float64 `form:"amount"`
+	Quantity int     `form:"quantity"`
+	Gift     bool    `form:"gift"`
+	Express  bool    `form:"express" form_profile:"strict"`
+	Weight   float32 `form:"weight" form_profile:"lenient"`
+}
+
+func Test_ParseProfile(t *testing.T) {
+	Convey("Parse numbers and booleans by profile", t, func() {
+		performProfileTest := func(profile, query string, expected priceForm, expectedErrors Errors) {
+			check := func(actual priceForm, errs Errors) {
+				So(actual, ShouldResemble, expected)
+				if len(expectedErrors) == 0 {
+					So(errs, ShouldBeEmpty)
+				} else {
+					So(errs, ShouldResemble, expectedErrors)
+				}
+			}
+
+			m := macaron.Classic()
+			if len(profile) > 0 {
+				b := NewBinder()
+				b.UseProfile(profile)
+				m.Get(testRoute, b.Handler(), Form(priceForm{}), check)
+			} else {
+				m.Get(testRoute, Form(priceForm{}), check)
+			}
+
+			req, err := http.NewRequest("GET", testRoute+query, nil)
+			So(err, ShouldBeNil)
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+		}
+
+		Convey("Default profile", func() {
+			performProfileTest("", "?amount=1234.56&gift=on&weight=+1,500.5+",
+				priceForm{Amount: 1234.56, Gift: true, Weight: 1500.5}, nil)
+			performProfileTest("", "?amount=1,234.56&gift=yes",
+				priceForm{},
+				Errors{
+					{FieldNames: []string{"amount"}, Classification: ERR_FLOAT_TYPE, Message: "Value could not be parsed as 64-bit float"},
+					{FieldNames: []string{"gift"}, Classification: ERR_BOOLEAN_TYPE, Message: "Value could not be parsed as boolean"},
+				})
+			performProfileTest("", "?gift=ON",
+				priceForm{},
+				Errors{
+					{FieldNames: []string{"gift"}, Classification: ERR_BOOLEAN_TYPE, Message: "Value could not be parsed as boolean"},
+				})
+		})
+
+		Convey("Lenient profile", func() {
+			performProfileTest("lenient", "?amount=+1,234.56&quantity=1,000&gift=YES",
+				priceForm{Amount: 1234.56, Quantity: 1000, Gift: true}, nil)
+			performProfileTest("lenient", "?amount=12,34.5&quantity=1,5&express=on",
+				priceForm{},
+				Errors{
+					{FieldNames: []string{"amount"}, Classification: ERR_FLOAT_TYPE, Message: "Value could not be parsed as 64-bit float"},
+					{FieldNames: []string{"quantity"}, Classification: ERR_INTERGER_TYPE, Message: "Value could not be parsed as integer"},
+					{FieldNames: []string{"express"}, Classification: ERR_BOOLEAN_TYPE, Message: "Value could not be parsed as boolean"},
+				})
+		})
+
+		Convey("German profile", func() {
+			performProfileTest("de", "?amount=-1.234,56&quantity=2.000&gift=ja&express=1",
+				priceForm{Amount: -1234.56, Quantity: 2000, Gift: true, Express: true}, nil)
+			performProfileTest("de", "?amount=1.5&gift=nein",
+				priceForm{},
+				Errors{
+					{FieldNames: []string{"amount"}, Classification: ERR_FLOAT_TYPE, Message: "Value could not be parsed as 64-bit float"},
+				})
+		})
+
+		Convey("Unknown profile", func() {
+			So(func() { NewBinder().UseProfile("fr") }, ShouldPanic)
+			So(func() {
+				Form(struct {
+					Amount float64 `form:"amount" form_profile:"fr"`
+				}{})
+			}, ShouldPanic)
+		})
+
+		Convey("Profile tags of other packages are left alone", func() {
+			So(func() {
+				Bind(struct {
+					Amount float64 `json:"amount" profile:"fr"`
+				}{})
+			}, ShouldNotPanic)
+		})
+	})
+}
@@ -18,44 +18,45 @@ package binding
 
 import (
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"mime/multipart"
 	"net/http"
 	"net/url"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"unicode/utf8"
 
+	"github.com/fxamacker/cbor/v2"
+	"github.com/pelletier/go-toml/v2"
 	"github.com/unknwon/com"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
 	"gopkg.in/macaron.v1"
-	"gopkg.in/yaml.v3"
 )
 
 func bind(ctx *macaron.Context, obj interface{}, ifacePtr ...interface{}) {
 	contentType := ctx.Req.Header.Get("Content-Type")
 	if ctx.Req.Method == "POST" || ctx.Req.Method == "PUT" || ctx.Req.Method == "PATCH" || ctx.Req.Method == "DELETE" {
-		switch {
-		case strings.Contains(contentType, "form-urlencoded"):
-			_, _ = ctx.Invoke(Form(obj, ifacePtr...))
-		case strings.Contains(contentType, "multipart/form-data"):
-			_, _ = ctx.Invoke(MultipartForm(obj, ifacePtr...))
-		case strings.Contains(contentType, "json"):
-			_, _ = ctx.Invoke(Json(obj, ifacePtr...))
-		case strings.Contains(contentType, "yaml"):
-			_, _ = ctx.Invoke(Yaml(obj, ifacePtr...))
-		default:
-			var errors Errors
-			if contentType == "" {
-				errors.Add([]string{}, ERR_CONTENT_TYPE, "Empty Content-Type")
-			} else {
-				errors.Add([]string{}, ERR_CONTENT_TYPE, "Unsupported Content-Type")
-			}
-			ctx.Map(errors)
-			ctx.Map(obj) // Map a fake struct so handler won't panic.
+		if binder := lookupBinder(contentType); binder != nil {
+			_, _ = ctx.Invoke(binder(obj, ifacePtr...))
+			return
 		}
+
+		var errors Errors
+		if contentType == "" {
+			errors.Add([]string{}, ERR_CONTENT_TYPE, "Empty Content-Type")
+		} else {
+			errors.Add([]string{}, ERR_CONTENT_TYPE, "Unsupported Content-Type")
+		}
+		ctx.Map(errors)
+		ctx.Map(obj) // Map a fake struct so handler won't panic.
 	} else {
 		_, _ = ctx.Invoke(Form(obj, ifacePtr...))
 	}
@@ -64,6 +65,12 @@ func bind(ctx *macaron.Context, obj interface{}, ifacePtr ...interface{}) {
 const (
 	_JSON_CONTENT_TYPE          = "application/json; charset=utf-8"
 	_YAML_CONTENT_TYPE          = "text/yaml; charset=utf-8"
+	_TOML_CONTENT_TYPE          = "application/toml; charset=utf-8"
+	_XML_CONTENT_TYPE           = "application/xml; charset=utf-8"
+	_MSGPACK_CONTENT_TYPE       = "application/msgpack"
+	_CBOR_CONTENT_TYPE          = "application/cbor"
+	_PROTOBUF_CONTENT_TYPE      = "application/x-protobuf"
+	_CSV_CONTENT_TYPE           = "text/csv; charset=utf-8"
 	STATUS_UNPROCESSABLE_ENTITY = 422
 )
 
@@ -79,9 +86,13 @@ const (
 func errorHandler(errs Errors, rw http.ResponseWriter) {
 	if len(errs) > 0 {
 		rw.Header().Set("Content-Type", _JSON_CONTENT_TYPE)
-		if errs.Has(ERR_DESERIALIZATION) {
+		if errs.Has(ERR_BODY_TOO_LARGE) {
+			rw.WriteHeader(http.StatusRequestEntityTooLarge)
+		} else if errs.Has(ERR_DESERIALIZATION) || errs.Has(ERR_UNKNOWN_FIELD) ||
+			errs.Has(ERR_DUPLICATE_KEY) || errs.Has(ERR_TRAILING_DATA) ||
+			errs.Has(ERR_LIMIT_EXCEEDED) {
 			rw.WriteHeader(http.StatusBadRequest)
-		} else if errs.Has(ERR_CONTENT_TYPE) {
+		} else if errs.Has(ERR_CONTENT_TYPE) || errs.Has(ERR_CONTENT_ENCODING) {
 			rw.WriteHeader(http.StatusUnsupportedMediaType)
 		} else {
 			rw.WriteHeader(STATUS_UNPROCESSABLE_ENTITY)
@@ -95,15 +106,19 @@ func errorHandler(errs Errors, rw http.ResponseWriter) {
 // CustomErrorHandler will be invoked if errors occured.
 var CustomErrorHandler func(*macaron.Context, Errors)
 
-// Bind wraps up the functionality of the Form and Json middleware
-// according to the Content-Type and verb of the request.
-// A Content-Type is required for POST and PUT requests.
+// Bind wraps up the functionality of the Form, Json, Yaml, Toml, Xml,
+// Msgpack, Cbor and Protobuf middleware according to the Content-Type and
+// verb of the request.
+// A Content-Type is required for POST and PUT requests; decoders for
+// additional Content-Types can be added with RegisterDecoder.
 // Bind invokes the ErrorHandler middleware to bail out if errors
 // occurred. If you want to perform your own error handling, use
 // Form or Json middleware directly. An interface pointer can
 // be added as a second argument in order to map the struct to
 // a specific interface.
 func Bind(obj interface{}, ifacePtr ...interface{}) macaron.Handler {
+	checkTags(reflect.TypeOf(obj))
+
 	return func(ctx *macaron.Context) {
 		bind(ctx, obj, ifacePtr...)
 		if handler, ok := obj.(ErrorHandler); ok {
@@ -120,6 +135,8 @@ func Bind(obj interface{}, ifacePtr ...interface{}) macaron.Handler {
 // error handling, which user has freedom to deal with them.
 // This allows user take advantages of validation.
 func BindIgnErr(obj interface{}, ifacePtr ...interface{}) macaron.Handler {
+	checkTags(reflect.TypeOf(obj))
+
 	return func(ctx *macaron.Context) {
 		bind(ctx, obj, ifacePtr...)
 	}
@@ -131,26 +148,49 @@ func BindIgnErr(obj interface{}, ifacePtr ...interface{}) macaron.Handler {
 // to perform deserialization, then reflection is used to map each field
 // into the struct with the proper type. Structs with primitive slice types
 // (bool, float, int, string) can support deserialization of repeated form
-// keys, for example: key=val1&key=val2&key=val3
+// keys, for example: key=val1&key=val2&key=val3, or of delimited values
+// as given by their collection_format tag (csv, ssv, tsv, pipes or multi),
+// for example: key=val1,val2,val3
+// Fields of type time.Time, laid out as given by their time_format tag,
+// time.Duration and types implementing encoding.TextUnmarshaler are
+// converted as well. Pointer fields are only set for keys that are present,
+// so that Required and OmitEmpty tell absent values apart from zero ones.
+// Nested structs, slices, arrays and maps can be filled with nested keys,
+// for example: address.city=Berlin&items[0].name=foo&meta[color]=red.
+// Errors name top-level fields by their Go name, as Bind does for every
+// Content-Type, and nested fields by their full form path, elements by the
+// index they were sent with, e.g. "items[5].name".
+// Arrays must be given exactly as many values as their length.
 // An interface pointer can be added as a second argument in order
 // to map the struct to a specific interface.
 func Form(formStruct interface{}, ifacePtr ...interface{}) macaron.Handler {
+	checkTags(reflect.TypeOf(formStruct))
+
 	return func(ctx *macaron.Context) {
 		var errors Errors
+		b := binderOf(ctx)
 
 		ensureNotPointer(formStruct)
 		formStruct := reflect.New(reflect.TypeOf(formStruct))
-		parseErr := ctx.Req.ParseForm()
+		parseErr := decompressBody(ctx)
+		if parseErr == nil {
+			parseErr = ctx.Req.ParseForm()
+		}
 
 		// Format validation of the request body or the URL would add considerable overhead,
 		// and ParseForm does not complain when URL encoding is off.
 		// Because an empty request body or url can also mean absence of all needed values,
 		// it is not in all cases a bad request, so let's return 422.
 		if parseErr != nil {
-			errors.Add([]string{}, ERR_DESERIALIZATION, parseErr.Error())
+			errors = addReadError(ctx, errors, parseErr)
 		}
-		errors = mapForm(formStruct, ctx.Req.Form, nil, errors)
-		validateAndMap(formStruct, ctx, errors, ifacePtr...)
+		if hasReadError(errors) {
+			mapUnvalidated(formStruct, ctx, errors, ifacePtr...)
+			return
+		}
+		indices := formIndices{}
+		errors = b.mapFormAt(formStruct, "", ctx.Req.Form, nil, indices, errors)
+		validateFormAndMap(formStruct, ctx, errors, indices, ifacePtr...)
 	}
 }
 
@@ -163,103 +203,250 @@ var MaxMemory = int64(1024 * 1024 * 10)
 // you can pass in an interface to make the interface available for injection
 // into other handlers later.
 func MultipartForm(formStruct interface{}, ifacePtr ...interface{}) macaron.Handler {
+	checkTags(reflect.TypeOf(formStruct))
+
 	return func(ctx *macaron.Context) {
 		var errors Errors
+		b := binderOf(ctx)
 		ensureNotPointer(formStruct)
 		formStruct := reflect.New(reflect.TypeOf(formStruct))
 		// This if check is necessary due to https://github.com/martini-contrib/csrf/issues/6
 		if ctx.Req.MultipartForm == nil {
 			// Workaround for multipart forms returning nil instead of an error
 			// when content is not multipart; see https://code.google.com/p/go/issues/detail?id=6334
-			if multipartReader, err := ctx.Req.MultipartReader(); err != nil {
+			if err := decompressBody(ctx); err != nil {
+				errors = addReadError(ctx, errors, err)
+			} else if multipartReader, err := ctx.Req.MultipartReader(); err != nil {
 				errors.Add([]string{}, ERR_DESERIALIZATION, err.Error())
 			} else {
-				form, parseErr := multipartReader.ReadForm(MaxMemory)
-				if parseErr != nil {
-					errors.Add([]string{}, ERR_DESERIALIZATION, parseErr.Error())
-				}
+				// ReadForm returns no form at all when it fails.
+				if form, parseErr := multipartReader.ReadForm(MaxMemory); parseErr != nil {
+					errors = addReadError(ctx, errors, parseErr)
+				} else {
+					if ctx.Req.Form == nil {
+						_ = ctx.Req.ParseForm()
+					}
+					for k, v := range form.Value {
+						ctx.Req.Form[k] = append(ctx.Req.Form[k], v...)
+					}
 
-				if ctx.Req.Form == nil {
-					_ = ctx.Req.ParseForm()
-				}
-				for k, v := range form.Value {
-					ctx.Req.Form[k] = append(ctx.Req.Form[k], v...)
+					ctx.Req.MultipartForm = form
 				}
-
-				ctx.Req.MultipartForm = form
 			}
 		}
-		errors = mapForm(formStruct, ctx.Req.MultipartForm.Value, ctx.Req.MultipartForm.File, errors)
-		validateAndMap(formStruct, ctx, errors, ifacePtr...)
+		if hasReadError(errors) {
+			mapUnvalidated(formStruct, ctx, errors, ifacePtr...)
+			return
+		}
+		indices := formIndices{}
+		if ctx.Req.MultipartForm != nil {
+			errors = b.mapFormAt(formStruct, "", ctx.Req.MultipartForm.Value, ctx.Req.MultipartForm.File, indices, errors)
+		}
+		validateFormAndMap(formStruct, ctx, errors, indices, ifacePtr...)
 	}
 }
 
 // Json is middleware to deserialize a JSON payload from the request
 // into the struct that is passed in. The resulting struct is then
 // validated, but no error handling is actually performed here.
+// The payload is decoded strictly if the Binder or the model asks for
+// it, see Binder.SetStrictJson. Decode errors name the field by its path,
+// e.g. "items[1].sku", and locate the problem in the payload.
 // An interface pointer can be added as a second argument in order
 // to map the struct to a specific interface.
 func Json(jsonStruct interface{}, ifacePtr ...interface{}) macaron.Handler {
-	return func(ctx *macaron.Context) {
-		var errors Errors
-		ensureNotPointer(jsonStruct)
-		jsonStruct := reflect.New(reflect.TypeOf(jsonStruct))
-		if ctx.Req.Request.Body != nil {
-			defer ctx.Req.Request.Body.Close()
-			err := json.NewDecoder(ctx.Req.Request.Body).Decode(jsonStruct.Interface())
-			if err != nil && err != io.EOF {
-				errors.Add([]string{}, ERR_DESERIALIZATION, err.Error())
-			}
-		}
-		if errors != nil {
-			ctx.Map(errors)
-			return
-		}
-		validateAndMap(jsonStruct, ctx, errors, ifacePtr...)
-	}
+	return Decode(jsonDecoder{}, jsonStruct, ifacePtr...)
 }
 
 // Yaml is middleware to deserialize a YAML payload from the request
 // into the struct that is passed in. The resulting struct is then
 // validated, but no error handling is actually performed here.
+// Decode errors name the field by its path in the document, e.g.
+// "items[1].sku", and locate the problem in it. The payload is decoded
+// strictly and within limits if the Binder or the model asks for it, see
+// Binder.SetStrictYaml and Binder.SetYamlLimits.
 // An interface pointer can be added as a second argument in order
 // to map the struct to a specific interface.
 func Yaml(yamlStruct interface{}, ifacePtr ...interface{}) macaron.Handler {
+	return Decode(yamlDecoder{}, yamlStruct, ifacePtr...)
+}
+
+// Toml is middleware to deserialize a TOML payload from the request
+// into the struct that is passed in. The resulting struct is then
+// validated, but no error handling is actually performed here.
+// An interface pointer can be added as a second argument in order
+// to map the struct to a specific interface.
+func Toml(tomlStruct interface{}, ifacePtr ...interface{}) macaron.Handler {
+	return Decode(DecoderFunc(decodeToml), tomlStruct, ifacePtr...)
+}
+
+func decodeToml(r io.Reader, v interface{}) error {
+	err := toml.NewDecoder(r).Decode(v)
+	if derr, ok := err.(*toml.DecodeError); ok {
+		line, column := derr.Position()
+		return Error{
+			FieldNames:     []string{},
+			Classification: ERR_DESERIALIZATION,
+			Message:        derr.Error(),
+			Line:           line,
+			Column:         column,
+		}
+	}
+	return err
+}
+
+// Xml is middleware to deserialize an XML payload from the request
+// into the struct that is passed in. The resulting struct is then
+// validated, but no error handling is actually performed here.
+// An interface pointer can be added as a second argument in order
+// to map the struct to a specific interface.
+func Xml(xmlStruct interface{}, ifacePtr ...interface{}) macaron.Handler {
+	return Decode(DecoderFunc(decodeXml), xmlStruct, ifacePtr...)
+}
+
+func decodeXml(r io.Reader, v interface{}) error {
+	return xml.NewDecoder(r).Decode(v)
+}
+
+// Msgpack is middleware to deserialize a MessagePack payload from the
+// request into the struct that is passed in. Fields are named after their
+// msgpack tag or, failing that, their json tag. The resulting struct is then
+// validated, but no error handling is actually performed here.
+// An interface pointer can be added as a second argument in order
+// to map the struct to a specific interface.
+func Msgpack(msgpackStruct interface{}, ifacePtr ...interface{}) macaron.Handler {
+	return Decode(DecoderFunc(decodeMsgpack), msgpackStruct, ifacePtr...)
+}
+
+func decodeMsgpack(r io.Reader, v interface{}) error {
+	dec := msgpack.NewDecoder(r)
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+// Cbor is middleware to deserialize a CBOR payload from the request
+// into the struct that is passed in. Fields are named after their cbor
+// tag or, failing that, their json tag. The resulting struct is then
+// validated, but no error handling is actually performed here.
+// An interface pointer can be added as a second argument in order
+// to map the struct to a specific interface.
+func Cbor(cborStruct interface{}, ifacePtr ...interface{}) macaron.Handler {
+	return Decode(DecoderFunc(decodeCbor), cborStruct, ifacePtr...)
+}
+
+func decodeCbor(r io.Reader, v interface{}) error {
+	return cbor.NewDecoder(r).Decode(v)
+}
+
+// Protobuf is middleware to deserialize a Protocol Buffers payload from
+// the request into the generated message struct that is passed in.
+// The resulting struct is then validated, but no error handling is
+// actually performed here. Generated structs cannot carry binding tags,
+// so use RegisterRules to attach validation rules to them.
+// Since generated messages must not be copied, only a pointer to the
+// message is mapped to the context. An interface pointer can be added
+// as a second argument in order to map the message pointer to a specific
+// interface, such as proto.Message.
+func Protobuf(pbStruct interface{}, ifacePtr ...interface{}) macaron.Handler {
 	return func(ctx *macaron.Context) {
 		var errors Errors
-		ensureNotPointer(yamlStruct)
-		yamlStruct := reflect.New(reflect.TypeOf(yamlStruct))
-		if ctx.Req.Request.Body != nil {
-			defer ctx.Req.Request.Body.Close()
-			err := yaml.NewDecoder(ctx.Req.Request.Body).Decode(yamlStruct.Interface())
-			if err != nil && err != io.EOF {
-				errors.Add([]string{}, ERR_DESERIALIZATION, err.Error())
-			}
-		}
+		ensureNotPointer(pbStruct)
+		pbStruct := reflect.New(reflect.TypeOf(pbStruct))
+		errors = decodeBody(ctx, DecoderFunc(decodeProtobuf), pbStruct, errors)
 		if errors != nil {
-			ctx.Map(errors)
-			return
+			// A message filled from part of a body is not worth validating.
+			pbStruct = reflect.New(pbStruct.Elem().Type())
+		} else {
+			_, _ = ctx.Invoke(Validate(pbStruct.Interface()))
+			errors = append(errors, getErrors(ctx)...)
+		}
+		ctx.Map(errors)
+		ctx.Map(pbStruct.Interface())
+		if _, ok := pbStruct.Interface().(proto.Message); !ok {
+			// Bind serves any model, and one that is not a message may be
+			// copied; it is mapped as well so that handlers won't panic.
+			ctx.Map(pbStruct.Elem().Interface())
 		}
-		validateAndMap(yamlStruct, ctx, errors, ifacePtr...)
+		if len(ifacePtr) > 0 {
+			ctx.MapTo(pbStruct.Interface(), ifacePtr[0])
+		}
+	}
+}
+
+func decodeProtobuf(r io.Reader, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return Error{Classification: ERR_CONTENT_TYPE, Message: "Unsupported Content-Type"}
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
 	}
+	return proto.Unmarshal(data, msg)
 }
 
 // URL is the middleware to parse URL parameters into struct fields.
+// Parameters are matched to fields the same way Form matches keys: by
+// form tag, or else by name mapper, so `:kind` binds to a Kind field, and
+// failing that by field name, so `:Kind` does as well. Values are
+// converted the same way as form values. Glob parameters are named "*"
+// (or "*0", "*1" and so on by level) and are split on slashes when bound
+// to a slice field. Errors name fields by the parameter they are bound from.
+// An interface pointer can be added as a second argument in order
+// to map the struct to a specific interface.
 func URL(obj interface{}, ifacePtr ...interface{}) macaron.Handler {
+	checkTags(reflect.TypeOf(obj))
+
 	return func(ctx *macaron.Context) {
 		var errors Errors
+		b := binderOf(ctx)
 
 		ensureNotPointer(obj)
 		obj := reflect.New(reflect.TypeOf(obj))
+		errors = b.mapParams(obj, ctx.AllParams(), errors)
+		mapValidated(obj, ctx, validate(obj.Interface(), paramFieldName(ctx.AllParams())), errors, ifacePtr...)
+	}
+}
 
-		val := obj.Elem()
-		for k, v := range ctx.AllParams() {
-			field := val.FieldByName(k[1:])
-			if field.IsValid() {
-				errors = setWithProperType(field.Kind(), v, field, k, errors)
-			}
-		}
-		validateAndMap(obj, ctx, errors, ifacePtr...)
+// Header is middleware to bind request headers into the struct fields
+// tagged with the header name, e.g. `header:"X-Request-Id"`. Values are
+// converted the same way as form values, and slice fields get every value
+// of a header, whether sent as repeated lines or as a comma-separated list.
+// Errors name fields by the header name in their tag, as it is written.
+// An interface pointer can be added as a second argument in order
+// to map the struct to a specific interface.
+func Header(headerStruct interface{}, ifacePtr ...interface{}) macaron.Handler {
+	checkTags(reflect.TypeOf(headerStruct))
+
+	return func(ctx *macaron.Context) {
+		var errors Errors
+		b := binderOf(ctx)
+
+		ensureNotPointer(headerStruct)
+		headerStruct := reflect.New(reflect.TypeOf(headerStruct))
+		errors = b.mapHeader(headerStruct, ctx.Req.Header, errors)
+		mapValidated(headerStruct, ctx, validate(headerStruct.Interface(), taggedFieldName("header")), errors, ifacePtr...)
+	}
+}
+
+// Cookie is middleware to bind request cookies into the struct fields
+// tagged with the cookie name, e.g. `cookie:"session_pref"`. Values are
+// converted the same way as form values, and slice fields get the values
+// of every cookie sent with the name. Errors name fields by the cookie name
+// in their tag.
+// An interface pointer can be added as a second argument in order
+// to map the struct to a specific interface.
+func Cookie(cookieStruct interface{}, ifacePtr ...interface{}) macaron.Handler {
+	checkTags(reflect.TypeOf(cookieStruct))
+
+	return func(ctx *macaron.Context) {
+		var errors Errors
+		b := binderOf(ctx)
+
+		ensureNotPointer(cookieStruct)
+		cookieStruct := reflect.New(reflect.TypeOf(cookieStruct))
+		errors = b.mapCookie(cookieStruct, ctx.Req.Cookies(), errors)
+		mapValidated(cookieStruct, ctx, validate(cookieStruct.Interface(), taggedFieldName("cookie")), errors, ifacePtr...)
 	}
 }
 
@@ -290,8 +477,13 @@ func RawValidate(obj interface{}) Errors {
 // is executed, and its errors are mapped to the context. This middleware
 // performs no error handling: it merely detects errors and maps them.
 func Validate(obj interface{}) macaron.Handler {
+	return validate(obj, goFieldName)
+}
+
+func validate(obj interface{}, namer fieldNamer) macaron.Handler {
 	return func(ctx *macaron.Context) {
 		var errs Errors
+		b := binderOf(ctx)
 		v := reflect.ValueOf(obj)
 		k := v.Kind()
 		if k == reflect.Interface || k == reflect.Ptr {
@@ -301,13 +493,13 @@ func Validate(obj interface{}) macaron.Handler {
 		if k == reflect.Slice || k == reflect.Array {
 			for i := 0; i < v.Len(); i++ {
 				e := v.Index(i).Interface()
-				errs = validateStruct(errs, e)
+				errs = b.validateStructAt(errs, e, "", namer)
 				if validator, ok := e.(Validator); ok {
 					errs = validator.Validate(ctx, errs)
 				}
 			}
 		} else {
-			errs = validateStruct(errs, obj)
+			errs = b.validateStructAt(errs, obj, "", namer)
 			if validator, ok := obj.(Validator); ok {
 				errs = validator.Validate(ctx, errs)
 			}
@@ -392,6 +584,49 @@ func AddParamRule(r *ParamRule) {
 	paramRuleMapper = append(paramRuleMapper, r)
 }
 
+var (
+	fieldRulesLock sync.RWMutex
+	fieldRules     = map[reflect.Type]map[string]string{}
+)
+
+// RegisterRules attaches validation rules to the fields of a struct type
+// that cannot carry binding tags itself, e.g. one generated from a .proto
+// file. The rules map Go field names to rules written as in a binding tag,
+// for example {"Name": "Required;MaxSize(50)"}, and are applied after the
+// ones from the binding tag, if any. The obj may be a struct or a pointer
+// to one.
+func RegisterRules(obj interface{}, rules map[string]string) {
+	typ := reflect.TypeOf(obj)
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	fieldRulesLock.Lock()
+	defer fieldRulesLock.Unlock()
+	if fieldRules[typ] == nil {
+		fieldRules[typ] = make(map[string]string, len(rules))
+	}
+	for name, rule := range rules {
+		fieldRules[typ][name] = rule
+	}
+}
+
+// bindingRules returns the validation rules of a struct field: those of
+// its binding tag followed by the ones registered with RegisterRules.
+func bindingRules(typ reflect.Type, field reflect.StructField) string {
+	rules := field.Tag.Get("binding")
+
+	fieldRulesLock.RLock()
+	defer fieldRulesLock.RUnlock()
+	if extra := fieldRules[typ][field.Name]; len(extra) > 0 {
+		if len(rules) > 0 {
+			return rules + ";" + extra
+		}
+		return extra
+	}
+	return rules
+}
+
 func in(fieldValue interface{}, arr string) bool {
 	val := fmt.Sprintf("%v", fieldValue)
 	vals := strings.Split(arr, ",")
@@ -405,6 +640,11 @@ func in(fieldValue interface{}, arr string) bool {
 	return isIn
 }
 
+// formName returns the name a field is bound by in a form.
+func formName(field reflect.StructField) string {
+	return parseFormName(field.Name, field.Tag.Get("form"))
+}
+
 func parseFormName(raw, actual string) string {
 	if len(actual) > 0 {
 		return actual
@@ -412,8 +652,56 @@ func parseFormName(raw, actual string) string {
 	return nameMapper(raw)
 }
 
+// fieldNamer names a field in validation errors, given the path of the
+// struct holding it, e.g. "items[2]", which is empty at the top level.
+type fieldNamer func(path string, field reflect.StructField) string
+
+// goFieldName names fields by their Go name alone.
+func goFieldName(_ string, field reflect.StructField) string {
+	return field.Name
+}
+
+// nestedFormPath names top-level fields by their Go name, and nested ones
+// by their full form path, e.g. "items[2].name".
+func nestedFormPath(path string, field reflect.StructField) string {
+	if len(path) == 0 {
+		return field.Name
+	}
+	return formFieldPath(path, field)
+}
+
+// formFieldPath names fields by their full form path, e.g. "items[2].name".
+func formFieldPath(path string, field reflect.StructField) string {
+	return joinFormPath(path, formName(field))
+}
+
+// taggedFieldName names fields by the name in the given tag, the way
+// Header and Cookie bind them, and untagged fields by their Go name.
+func taggedFieldName(tag string) fieldNamer {
+	return func(_ string, field reflect.StructField) string {
+		if name := field.Tag.Get(tag); len(name) > 0 && name != "-" {
+			return name
+		}
+		return field.Name
+	}
+}
+
+// paramFieldName names fields by the route parameter they are bound from,
+// the way URL binds them.
+func paramFieldName(params macaron.Params) fieldNamer {
+	return func(_ string, field reflect.StructField) string {
+		return paramName(params, field)
+	}
+}
+
 // Performs required field checking on a struct
 func validateStruct(errors Errors, obj interface{}) Errors {
+	return defaultBinder.validateStructAt(errors, obj, "", goFieldName)
+}
+
+// validateStructAt validates the struct found at the given path of the
+// model, naming fields in errors with namer.
+func (b *Binder) validateStructAt(errors Errors, obj interface{}, path string, namer fieldNamer) Errors {
 	typ := reflect.TypeOf(obj)
 	val := reflect.ValueOf(obj)
 
@@ -433,107 +721,166 @@ func validateStruct(errors Errors, obj interface{}) Errors {
 		fieldVal := val.Field(i)
 		fieldValue := fieldVal.Interface()
 		zero := reflect.Zero(field.Type).Interface()
+		fieldPath := path
+		if !field.Anonymous {
+			fieldPath = joinFormPath(path, formName(field))
+		}
+
+		// Validate nested and embedded structs, and those held by
+		// pointers, interfaces, Optionals, slices and arrays.
+		errors = b.validateNested(errors, fieldVal, fieldPath, namer)
+		errors = b.validateField(errors, zero, field, namer(path, field), bindingRules(typ, field), fieldVal, fieldValue)
+	}
+	return errors
+}
+
+// validateNested validates the structs held by a value: the value itself
+// if it is one, or those it holds through non-nil pointers and interfaces,
+// present Optionals and elements of slices and arrays, the latter named by
+// index, e.g. "items[2].name".
+func (b *Binder) validateNested(errors Errors, v reflect.Value, path string, namer fieldNamer) Errors {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return errors
+		}
+		v = v.Elem()
+	}
+	if opt, ok := optionalOf(v); ok {
+		if opt.present() {
+			errors = b.validateNested(errors, reflect.ValueOf(opt.value()), path, namer)
+		}
+		return errors
+	}
 
-		// Validate nested and embedded structs (if pointer, only do so if not nil)
-		if field.Type.Kind() == reflect.Struct ||
-			(field.Type.Kind() == reflect.Ptr && !reflect.DeepEqual(zero, fieldValue) &&
-				field.Type.Elem().Kind() == reflect.Struct) {
-			errors = validateStruct(errors, fieldValue)
+	switch v.Kind() {
+	case reflect.Struct:
+		errors = b.validateStructAt(errors, v.Interface(), path, namer)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			errors = b.validateNested(errors, v.Index(i), fmt.Sprintf("%s[%d]", path, i), namer)
 		}
-		errors = validateField(errors, zero, field, fieldVal, fieldValue)
 	}
 	return errors
 }
 
-func validateField(errors Errors, zero interface{}, field reflect.StructField, fieldVal reflect.Value, fieldValue interface{}) Errors {
-	if fieldVal.Kind() == reflect.Slice {
-		for i := 0; i < fieldVal.Len(); i++ {
-			sliceVal := fieldVal.Index(i)
-			if sliceVal.Kind() == reflect.Ptr {
-				sliceVal = sliceVal.Elem()
-			}
+// presentValue returns the value held by a pointer or an Optional and
+// whether there is one. It reports false for fields of other types.
+func presentValue(fieldVal reflect.Value) (value interface{}, present, ok bool) {
+	if opt, ok := optionalOf(fieldVal); ok {
+		return opt.value(), opt.present(), true
+	}
+	if fieldVal.Kind() == reflect.Ptr {
+		if fieldVal.IsNil() {
+			return nil, false, true
+		}
+		return fieldVal.Elem().Interface(), true, true
+	}
+	return nil, false, false
+}
 
-			sliceValue := sliceVal.Interface()
-			zero := reflect.Zero(sliceVal.Type()).Interface()
-			if sliceVal.Kind() == reflect.Struct ||
-				(sliceVal.Kind() == reflect.Ptr && !reflect.DeepEqual(zero, sliceValue) &&
-					sliceVal.Elem().Kind() == reflect.Struct) {
-				errors = validateStruct(errors, sliceValue)
-			}
-			/* Apply validation rules to each item in a slice. ISSUE #3
-			else {
-				errors = validateField(errors, zero, field, sliceVal, sliceValue)
-			}*/
+// builtinRules are the rules, or the prefixes of those taking parameters,
+// that validateField checks on a value rather than passing to custom ones.
+var builtinRules = []string{
+	"AlphaDash", "AlphaDashDot", "Email", "Url",
+	"Size(", "MinSize(", "MaxSize(", "Range(", "In(", "NotIn(", "Include(", "Exclude(",
+}
+
+func isBuiltinRule(rule string) bool {
+	for _, r := range builtinRules {
+		if rule == r || (strings.HasSuffix(r, "(") && strings.HasPrefix(rule, r)) {
+			return true
 		}
 	}
+	return false
+}
+
+func (b *Binder) validateField(errors Errors, zero interface{}, field reflect.StructField, name, rules string, fieldVal reflect.Value, fieldValue interface{}) Errors {
+	// Pointers and Optionals are judged by presence: Required and OmitEmpty
+	// tell whether there is a value, and the built-in rules check that value.
+	// Custom rules get the field as it is.
+	value, present, judged := presentValue(fieldVal)
+	if !judged {
+		value, present = fieldValue, !reflect.DeepEqual(zero, fieldValue)
+	}
 
 VALIDATE_RULES:
-	for _, rule := range strings.Split(field.Tag.Get("binding"), ";") {
+	for _, rule := range strings.Split(rules, ";") {
 		if len(rule) == 0 {
 			continue
 		}
 
 		switch {
 		case rule == "OmitEmpty":
-			if reflect.DeepEqual(zero, fieldValue) {
+			if !present {
 				break VALIDATE_RULES
 			}
 		case rule == "Required":
 			v := reflect.ValueOf(fieldValue)
-			if v.Kind() == reflect.Slice {
+			if !judged && v.Kind() == reflect.Slice {
 				if v.Len() == 0 {
-					errors.Add([]string{field.Name}, ERR_REQUIRED, "Required")
+					errors.Add([]string{name}, ERR_REQUIRED, "Required")
 					break VALIDATE_RULES
 				}
 
 				continue
 			}
 
-			if reflect.DeepEqual(zero, fieldValue) {
-				errors.Add([]string{field.Name}, ERR_REQUIRED, "Required")
+			if !present {
+				errors.Add([]string{name}, ERR_REQUIRED, "Required")
 				break VALIDATE_RULES
 			}
+		case strings.HasPrefix(rule, "Default("):
+			if !present {
+				if fieldVal.CanAddr() {
+					errors = b.setFormValue(fieldVal, field.Tag, rule[8:len(rule)-1], field.Tag.Get("form"), errors)
+				} else {
+					errors.Add([]string{name}, ERR_EXCLUDE, "Default")
+					break VALIDATE_RULES
+				}
+			}
+		case !present && judged && isBuiltinRule(rule):
+			// Nothing to check on an absent value.
 		case rule == "AlphaDash":
-			if AlphaDashPattern.MatchString(fmt.Sprintf("%v", fieldValue)) {
-				errors.Add([]string{field.Name}, ERR_ALPHA_DASH, "AlphaDash")
+			if AlphaDashPattern.MatchString(fmt.Sprintf("%v", value)) {
+				errors.Add([]string{name}, ERR_ALPHA_DASH, "AlphaDash")
 				break VALIDATE_RULES
 			}
 		case rule == "AlphaDashDot":
-			if AlphaDashDotPattern.MatchString(fmt.Sprintf("%v", fieldValue)) {
-				errors.Add([]string{field.Name}, ERR_ALPHA_DASH_DOT, "AlphaDashDot")
+			if AlphaDashDotPattern.MatchString(fmt.Sprintf("%v", value)) {
+				errors.Add([]string{name}, ERR_ALPHA_DASH_DOT, "AlphaDashDot")
 				break VALIDATE_RULES
 			}
 		case strings.HasPrefix(rule, "Size("):
 			size, _ := strconv.Atoi(rule[5 : len(rule)-1])
-			if str, ok := fieldValue.(string); ok && utf8.RuneCountInString(str) != size {
-				errors.Add([]string{field.Name}, ERR_SIZE, "Size")
+			if str, ok := value.(string); ok && utf8.RuneCountInString(str) != size {
+				errors.Add([]string{name}, ERR_SIZE, "Size")
 				break VALIDATE_RULES
 			}
-			v := reflect.ValueOf(fieldValue)
+			v := reflect.ValueOf(value)
 			if v.Kind() == reflect.Slice && v.Len() != size {
-				errors.Add([]string{field.Name}, ERR_SIZE, "Size")
+				errors.Add([]string{name}, ERR_SIZE, "Size")
 				break VALIDATE_RULES
 			}
 		case strings.HasPrefix(rule, "MinSize("):
 			min, _ := strconv.Atoi(rule[8 : len(rule)-1])
-			if str, ok := fieldValue.(string); ok && utf8.RuneCountInString(str) < min {
-				errors.Add([]string{field.Name}, ERR_MIN_SIZE, "MinSize")
+			if str, ok := value.(string); ok && utf8.RuneCountInString(str) < min {
+				errors.Add([]string{name}, ERR_MIN_SIZE, "MinSize")
 				break VALIDATE_RULES
 			}
-			v := reflect.ValueOf(fieldValue)
+			v := reflect.ValueOf(value)
 			if v.Kind() == reflect.Slice && v.Len() < min {
-				errors.Add([]string{field.Name}, ERR_MIN_SIZE, "MinSize")
+				errors.Add([]string{name}, ERR_MIN_SIZE, "MinSize")
 				break VALIDATE_RULES
 			}
 		case strings.HasPrefix(rule, "MaxSize("):
 			max, _ := strconv.Atoi(rule[8 : len(rule)-1])
-			if str, ok := fieldValue.(string); ok && utf8.RuneCountInString(str) > max {
-				errors.Add([]string{field.Name}, ERR_MAX_SIZE, "MaxSize")
+			if str, ok := value.(string); ok && utf8.RuneCountInString(str) > max {
+				errors.Add([]string{name}, ERR_MAX_SIZE, "MaxSize")
 				break VALIDATE_RULES
 			}
-			v := reflect.ValueOf(fieldValue)
+			v := reflect.ValueOf(value)
 			if v.Kind() == reflect.Slice && v.Len() > max {
-				errors.Add([]string{field.Name}, ERR_MAX_SIZE, "MaxSize")
+				errors.Add([]string{name}, ERR_MAX_SIZE, "MaxSize")
 				break VALIDATE_RULES
 			}
 		case strings.HasPrefix(rule, "Range("):
@@ -541,59 +888,50 @@ VALIDATE_RULES:
 			if len(nums) != 2 {
 				break VALIDATE_RULES
 			}
-			val := com.StrTo(fmt.Sprintf("%v", fieldValue)).MustInt()
+			val := com.StrTo(fmt.Sprintf("%v", value)).MustInt()
 			if val < com.StrTo(nums[0]).MustInt() || val > com.StrTo(nums[1]).MustInt() {
-				errors.Add([]string{field.Name}, ERR_RANGE, "Range")
+				errors.Add([]string{name}, ERR_RANGE, "Range")
 				break VALIDATE_RULES
 			}
 		case rule == "Email":
-			if !EmailPattern.MatchString(fmt.Sprintf("%v", fieldValue)) {
-				errors.Add([]string{field.Name}, ERR_EMAIL, "Email")
+			if !EmailPattern.MatchString(fmt.Sprintf("%v", value)) {
+				errors.Add([]string{name}, ERR_EMAIL, "Email")
 				break VALIDATE_RULES
 			}
 		case rule == "Url":
-			str := fmt.Sprintf("%v", fieldValue)
+			str := fmt.Sprintf("%v", value)
 			if len(str) == 0 {
 				continue
 			} else if !isURL(str) {
-				errors.Add([]string{field.Name}, ERR_URL, "Url")
+				errors.Add([]string{name}, ERR_URL, "Url")
 				break VALIDATE_RULES
 			}
 		case strings.HasPrefix(rule, "In("):
-			if !in(fieldValue, rule[3:len(rule)-1]) {
-				errors.Add([]string{field.Name}, ERR_IN, "In")
+			if !in(value, rule[3:len(rule)-1]) {
+				errors.Add([]string{name}, ERR_IN, "In")
 				break VALIDATE_RULES
 			}
 		case strings.HasPrefix(rule, "NotIn("):
-			if in(fieldValue, rule[6:len(rule)-1]) {
-				errors.Add([]string{field.Name}, ERR_NOT_INT, "NotIn")
+			if in(value, rule[6:len(rule)-1]) {
+				errors.Add([]string{name}, ERR_NOT_INT, "NotIn")
 				break VALIDATE_RULES
 			}
 		case strings.HasPrefix(rule, "Include("):
-			if !strings.Contains(fmt.Sprintf("%v", fieldValue), rule[8:len(rule)-1]) {
-				errors.Add([]string{field.Name}, ERR_INCLUDE, "Include")
+			if !strings.Contains(fmt.Sprintf("%v", value), rule[8:len(rule)-1]) {
+				errors.Add([]string{name}, ERR_INCLUDE, "Include")
 				break VALIDATE_RULES
 			}
 		case strings.HasPrefix(rule, "Exclude("):
-			if strings.Contains(fmt.Sprintf("%v", fieldValue), rule[8:len(rule)-1]) {
-				errors.Add([]string{field.Name}, ERR_EXCLUDE, "Exclude")
+			if strings.Contains(fmt.Sprintf("%v", value), rule[8:len(rule)-1]) {
+				errors.Add([]string{name}, ERR_EXCLUDE, "Exclude")
 				break VALIDATE_RULES
 			}
-		case strings.HasPrefix(rule, "Default("):
-			if reflect.DeepEqual(zero, fieldValue) {
-				if fieldVal.CanAddr() {
-					errors = setWithProperType(field.Type.Kind(), rule[8:len(rule)-1], fieldVal, field.Tag.Get("form"), errors)
-				} else {
-					errors.Add([]string{field.Name}, ERR_EXCLUDE, "Default")
-					break VALIDATE_RULES
-				}
-			}
 		default:
 			// Apply custom validation rules
 			var isValid bool
 			for i := range ruleMapper {
 				if ruleMapper[i].IsMatch(rule) {
-					isValid, errors = ruleMapper[i].IsValid(errors, field.Name, fieldValue)
+					isValid, errors = ruleMapper[i].IsValid(errors, name, fieldValue)
 					if !isValid {
 						break VALIDATE_RULES
 					}
@@ -601,7 +939,7 @@ VALIDATE_RULES:
 			}
 			for i := range paramRuleMapper {
 				if paramRuleMapper[i].IsMatch(rule) {
-					isValid, errors = paramRuleMapper[i].IsValid(errors, rule, field.Name, fieldValue)
+					isValid, errors = paramRuleMapper[i].IsValid(errors, rule, name, fieldValue)
 					if !isValid {
 						break VALIDATE_RULES
 					}
@@ -637,8 +975,19 @@ func SetNameMapper(nm NameMapper) {
 }
 
 // Takes values from the form data and puts them into a struct
-func mapForm(formStruct reflect.Value, form map[string][]string,
+func (b *Binder) mapForm(formStruct reflect.Value, form map[string][]string,
 	formfile map[string][]*multipart.FileHeader, errors Errors) Errors {
+	return b.mapFormAt(formStruct, "", form, formfile, nil, errors)
+}
+
+// mapFormAt works like mapForm for the struct found at the given path of
+// the model. Besides flat keys, fields of nested structs are looked up by
+// nested keys, e.g. "address.city" or "address[city]", slices by indexed
+// keys, e.g. "items[0].name", "items[][name]" or "tags[]", and maps by
+// keyed ones, e.g. "meta[color]". The indices elements are sent with are
+// recorded in indices, unless it is nil.
+func (b *Binder) mapFormAt(formStruct reflect.Value, path string, form map[string][]string,
+	formfile map[string][]*multipart.FileHeader, indices formIndices, errors Errors) Errors {
 
 	if formStruct.Kind() == reflect.Ptr {
 		formStruct = formStruct.Elem()
@@ -651,32 +1000,31 @@ func mapForm(formStruct reflect.Value, form map[string][]string,
 
 		if typeField.Type.Kind() == reflect.Ptr && typeField.Anonymous {
 			structField.Set(reflect.New(typeField.Type.Elem()))
-			errors = mapForm(structField.Elem(), form, formfile, errors)
+			errors = b.mapFormAt(structField.Elem(), path, form, formfile, indices, errors)
 			if reflect.DeepEqual(structField.Elem().Interface(), reflect.Zero(structField.Elem().Type()).Interface()) {
 				structField.Set(reflect.Zero(structField.Type()))
 			}
-		} else if typeField.Type.Kind() == reflect.Struct {
-			errors = mapForm(structField, form, formfile, errors)
+		} else if typeField.Type.Kind() == reflect.Struct && !b.isTextValue(typeField.Type) {
+			errors = b.mapFormAt(structField, path, form, formfile, indices, errors)
 		}
 
-		inputFieldName := parseFormName(typeField.Name, typeField.Tag.Get("form"))
+		inputFieldName := formName(typeField)
 		if len(inputFieldName) == 0 || !structField.CanSet() {
 			continue
 		}
+		fieldPath := joinFormPath(path, inputFieldName)
+
+		if !typeField.Anonymous {
+			var mapped bool
+			errors, mapped = b.mapNestedForm(structField, typeField.Tag, fieldPath, subForm(form, inputFieldName), indices, errors)
+			if mapped {
+				continue
+			}
+		}
 
 		inputValue, exists := form[inputFieldName]
 		if exists {
-			numElems := len(inputValue)
-			if structField.Kind() == reflect.Slice && numElems > 0 {
-				sliceOf := structField.Type().Elem().Kind()
-				slice := reflect.MakeSlice(structField.Type(), numElems, numElems)
-				for i := 0; i < numElems; i++ {
-					errors = setWithProperType(sliceOf, inputValue[i], slice.Index(i), inputFieldName, errors)
-				}
-				formStruct.Field(i).Set(slice)
-			} else {
-				errors = setWithProperType(typeField.Type.Kind(), inputValue[0], structField, inputFieldName, errors)
-			}
+			errors = b.setFormValues(structField, typeField.Tag, inputValue, fieldPath, errors)
 			continue
 		}
 
@@ -699,18 +1047,344 @@ func mapForm(formStruct reflect.Value, form map[string][]string,
 	return errors
 }
 
+// mapNestedForm sets a struct, struct pointer, slice, array or map field
+// from the nested keys found for it, and reports whether there were any.
+func (b *Binder) mapNestedForm(field reflect.Value, tag reflect.StructTag, path string, sub map[string][]string, indices formIndices, errors Errors) (Errors, bool) {
+	if len(sub) == 0 || b.isTextValue(field.Type()) {
+		return errors, false
+	}
+
+	switch {
+	case field.Kind() == reflect.Struct:
+		return b.mapFormAt(field, path, sub, nil, indices, errors), true
+	case field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Struct && !b.isTextValue(field.Type().Elem()):
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		return b.mapFormAt(field, path, sub, nil, indices, errors), true
+	case field.Kind() == reflect.Slice || field.Kind() == reflect.Array:
+		return b.mapIndexedForm(field, tag, path, sub, indices, errors)
+	case field.Kind() == reflect.Map:
+		return b.mapKeyedForm(field, tag, path, sub, indices, errors), true
+	}
+	return errors, false
+}
+
+// mapKeyedForm sets a map field from its keyed entries, e.g. "meta[color]".
+// Keys and values are converted the same way as form values, and errors
+// name the entry by its full path, e.g. "meta[size]".
+func (b *Binder) mapKeyedForm(field reflect.Value, tag reflect.StructTag, path string, sub map[string][]string, indices formIndices, errors Errors) Errors {
+	entries := make(map[string]map[string][]string)
+	for key, values := range sub {
+		head, rest := splitFormKey(key)
+		if len(head) == 0 {
+			continue
+		}
+		if entries[head] == nil {
+			entries[head] = make(map[string][]string)
+		}
+		entries[head][rest] = append(entries[head][rest], values...)
+	}
+
+	heads := make([]string, 0, len(entries))
+	for head := range entries {
+		heads = append(heads, head)
+	}
+	sort.Strings(heads)
+
+	keyType, elemType := field.Type().Key(), field.Type().Elem()
+	if field.IsNil() {
+		field.Set(reflect.MakeMapWithSize(field.Type(), len(heads)))
+	}
+	for _, head := range heads {
+		entryPath := fmt.Sprintf("%s[%s]", path, head)
+		numErrors := len(errors)
+		key := reflect.New(keyType).Elem()
+		errors = b.setFormValue(key, "", head, entryPath, errors)
+		if len(errors) > numErrors {
+			continue
+		}
+
+		entry := entries[head]
+		value := reflect.New(elemType).Elem()
+		switch {
+		case elemType.Kind() == reflect.Struct && !b.isTextValue(elemType):
+			errors = b.mapFormAt(value, entryPath, entry, nil, indices, errors)
+		case b.isMultiValue(elemType):
+			errors = b.setFormValues(value, tag, entry[""], entryPath, errors)
+		default:
+			if values := entry[""]; len(values) > 0 {
+				errors = b.setFormValue(value, tag, values[0], entryPath, errors)
+			}
+		}
+		field.SetMapIndex(key, value)
+	}
+	return errors
+}
+
+// mapIndexedForm sets a slice or array field from its indexed keys.
+// Elements are ordered by index, and those with an empty index, e.g.
+// "items[][name]", come last, the n-th value of each key going to the n-th
+// of them. An array must get as many elements as its length. Errors name
+// elements by the index they were sent with, those with an empty one
+// being numbered on from the highest.
+func (b *Binder) mapIndexedForm(field reflect.Value, tag reflect.StructTag, path string, sub map[string][]string, indices formIndices, errors Errors) (Errors, bool) {
+	indexed := make(map[int]map[string][]string)
+	appended := make(map[string][]string)
+	for key, values := range sub {
+		index, rest := splitFormKey(key)
+		if len(index) == 0 {
+			appended[rest] = append(appended[rest], values...)
+			continue
+		}
+		n, err := strconv.Atoi(index)
+		if err != nil || n < 0 {
+			continue
+		}
+		if indexed[n] == nil {
+			indexed[n] = make(map[string][]string)
+		}
+		indexed[n][rest] = append(indexed[n][rest], values...)
+	}
+	if len(indexed) == 0 && len(appended) == 0 {
+		return errors, false
+	}
+
+	sent := make([]int, 0, len(indexed))
+	for n := range indexed {
+		sent = append(sent, n)
+	}
+	sort.Ints(sent)
+	elems := make([]map[string][]string, 0, len(sent))
+	for _, n := range sent {
+		elems = append(elems, indexed[n])
+	}
+	next := 0
+	if len(sent) > 0 {
+		next = sent[len(sent)-1] + 1
+	}
+	for n := 0; ; n++ {
+		elem := make(map[string][]string)
+		for rest, values := range appended {
+			if n < len(values) {
+				elem[rest] = values[n : n+1]
+			}
+		}
+		if len(elem) == 0 {
+			break
+		}
+		elems = append(elems, elem)
+		sent = append(sent, next+n)
+	}
+	if indices != nil {
+		indices[path] = sent
+	}
+
+	elemType := field.Type().Elem()
+	slice, ok := makeElems(field.Type(), len(elems), path, &errors)
+	if !ok {
+		return errors, true
+	}
+	for i, elem := range elems {
+		elemPath := fmt.Sprintf("%s[%d]", path, sent[i])
+		switch {
+		case elemType.Kind() == reflect.Struct && !b.isTextValue(elemType):
+			errors = b.mapFormAt(slice.Index(i), elemPath, elem, nil, indices, errors)
+		case elemType.Kind() == reflect.Ptr && elemType.Elem().Kind() == reflect.Struct:
+			slice.Index(i).Set(reflect.New(elemType.Elem()))
+			errors = b.mapFormAt(slice.Index(i), elemPath, elem, nil, indices, errors)
+		default:
+			if values := elem[""]; len(values) > 0 {
+				errors = b.setFormValue(slice.Index(i), tag, values[0], elemPath, errors)
+			}
+		}
+	}
+	field.Set(slice)
+	return errors, true
+}
+
+// subForm returns the keys nested under the given name, with the name
+// taken off: "address.city" and "address[city]" both give "city".
+func subForm(form map[string][]string, name string) map[string][]string {
+	var sub map[string][]string
+	for key, values := range form {
+		if len(key) <= len(name) || !strings.HasPrefix(key, name) {
+			continue
+		}
+		if c := key[len(name)]; c != '.' && c != '[' {
+			continue
+		}
+		if sub == nil {
+			sub = make(map[string][]string)
+		}
+		rest := unwrapFormKey(key[len(name):])
+		sub[rest] = append(sub[rest], values...)
+	}
+	return sub
+}
+
+// splitFormKey splits the first segment off a nested key:
+// "0.name" gives "0" and "name", "[name]" gives "" and "name".
+func splitFormKey(key string) (head, rest string) {
+	i := strings.IndexAny(key, ".[")
+	if i < 0 {
+		return key, ""
+	}
+	return key[:i], unwrapFormKey(key[i:])
+}
+
+// unwrapFormKey takes the separator off a nested key:
+// ".city" and "[city]" both give "city", "[geo][lat]" gives "geo[lat]".
+func unwrapFormKey(key string) string {
+	if strings.HasPrefix(key, ".") {
+		return key[1:]
+	}
+	if strings.HasPrefix(key, "[") {
+		if i := strings.IndexByte(key, ']'); i > 0 {
+			return key[1:i] + key[i+1:]
+		}
+	}
+	return key
+}
+
+// joinFormPath appends the name of a field to the path of its struct.
+func joinFormPath(path, name string) string {
+	if len(path) == 0 {
+		return name
+	}
+	return path + "." + name
+}
+
+// Takes values from the request headers and puts them into the struct
+// fields that have a header tag.
+func (b *Binder) mapHeader(headerStruct reflect.Value, header http.Header, errors Errors) Errors {
+	return b.mapTagged(headerStruct, "header", func(name string, field reflect.Value) []string {
+		return headerValues(header, name, b.isMultiValue(field.Type()))
+	}, errors)
+}
+
+// Takes values from the request cookies and puts them into the struct
+// fields that have a cookie tag.
+func (b *Binder) mapCookie(cookieStruct reflect.Value, cookies []*http.Cookie, errors Errors) Errors {
+	values := make(map[string][]string, len(cookies))
+	for _, cookie := range cookies {
+		values[cookie.Name] = append(values[cookie.Name], cookie.Value)
+	}
+	return b.mapTagged(cookieStruct, "cookie", func(name string, _ reflect.Value) []string {
+		return values[name]
+	}, errors)
+}
+
+// Takes values from the route parameters and puts them into the struct
+// fields named after them.
+func (b *Binder) mapParams(obj reflect.Value, params macaron.Params, errors Errors) Errors {
+	return b.mapNamed(obj, func(field reflect.StructField) string {
+		return paramName(params, field)
+	}, func(name string, field reflect.Value) []string {
+		return paramValues(params, name, b.isMultiValue(field.Type()))
+	}, errors)
+}
+
+// paramName returns the name of the route parameter a field is bound from:
+// its form name or, for routes written against field names such as "/:Id",
+// its Go name.
+func paramName(params macaron.Params, field reflect.StructField) string {
+	name := formName(field)
+	if name == "-" || strings.HasPrefix(name, "*") {
+		return name
+	}
+	if _, ok := params[":"+name]; !ok {
+		if _, ok := params[":"+field.Name]; ok {
+			return field.Name
+		}
+	}
+	return name
+}
+
+// paramValues returns the values of a route parameter: a glob parameter
+// gets one value per path segment when multi is set.
+func paramValues(params macaron.Params, name string, multi bool) []string {
+	key := ":" + name
+	if strings.HasPrefix(name, "*") {
+		key = name
+	}
+	value, ok := params[key]
+	if !ok {
+		return nil
+	}
+	if key != name || !multi {
+		return []string{value}
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(value, "/") {
+		if len(segment) > 0 {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// mapTagged sets every struct field that has the given tag, nested and
+// embedded structs included, from the values returned by lookup for the
+// name in the tag.
+func (b *Binder) mapTagged(obj reflect.Value, tag string, lookup func(name string, field reflect.Value) []string, errors Errors) Errors {
+	return b.mapNamed(obj, func(field reflect.StructField) string {
+		return field.Tag.Get(tag)
+	}, lookup, errors)
+}
+
+// mapNamed sets every struct field, nested and embedded structs included,
+// from the values returned by lookup for the name it is given by nameOf.
+// Fields named "" or "-" are skipped.
+func (b *Binder) mapNamed(obj reflect.Value, nameOf func(field reflect.StructField) string,
+	lookup func(name string, field reflect.Value) []string, errors Errors) Errors {
+
+	if obj.Kind() == reflect.Ptr {
+		obj = obj.Elem()
+	}
+	typ := obj.Type()
+
+	for i := 0; i < typ.NumField(); i++ {
+		typeField := typ.Field(i)
+		structField := obj.Field(i)
+
+		if typeField.Type.Kind() == reflect.Ptr && typeField.Anonymous {
+			structField.Set(reflect.New(typeField.Type.Elem()))
+			errors = b.mapNamed(structField.Elem(), nameOf, lookup, errors)
+			if reflect.DeepEqual(structField.Elem().Interface(), reflect.Zero(structField.Elem().Type()).Interface()) {
+				structField.Set(reflect.Zero(structField.Type()))
+			}
+		} else if typeField.Type.Kind() == reflect.Struct {
+			errors = b.mapNamed(structField, nameOf, lookup, errors)
+		}
+
+		name := nameOf(typeField)
+		if len(name) == 0 || name == "-" || !structField.CanSet() {
+			continue
+		}
+		errors = b.setFormValues(structField, typeField.Tag, lookup(name, structField), name, errors)
+	}
+	return errors
+}
+
 // This sets the value in a struct of an indeterminate type to the
 // matching value from the request (via Form middleware) in the
 // same type, so that not all deserialized values have to be strings.
-// Supported types are string, int, float, and bool.
-func setWithProperType(valueKind reflect.Kind, val string, structField reflect.Value, nameInTag string, errors Errors) Errors {
+// Supported types are string, int, float, complex, and bool. Numbers and
+// booleans are parsed as the profile says, integers in the given base, and
+// numbers out of range for the size of their type are reported as
+// ERR_OVERFLOW.
+func setWithProperType(valueKind reflect.Kind, val string, structField reflect.Value, profile *ParseProfile, base int, nameInTag string, errors Errors) Errors {
 	switch valueKind {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		if val == "" {
 			val = "0"
 		}
-		intVal, err := strconv.ParseInt(val, 10, 64)
-		if err != nil {
+		intVal, err := strconv.ParseInt(profile.number(val), base, structField.Type().Bits())
+		if isRangeError(err) {
+			errors.Add([]string{nameInTag}, ERR_OVERFLOW, "Value overflows "+valueKind.String())
+		} else if err != nil {
 			errors.Add([]string{nameInTag}, ERR_INTERGER_TYPE, "Value could not be parsed as integer")
 		} else {
 			structField.SetInt(intVal)
@@ -719,22 +1393,19 @@ func setWithProperType(valueKind reflect.Kind, val string, structField reflect.V
 		if val == "" {
 			val = "0"
 		}
-		uintVal, err := strconv.ParseUint(val, 10, 64)
-		if err != nil {
+		uintVal, err := strconv.ParseUint(profile.number(val), base, structField.Type().Bits())
+		if isRangeError(err) {
+			errors.Add([]string{nameInTag}, ERR_OVERFLOW, "Value overflows "+valueKind.String())
+		} else if err != nil {
 			errors.Add([]string{nameInTag}, ERR_INTERGER_TYPE, "Value could not be parsed as unsigned integer")
 		} else {
 			structField.SetUint(uintVal)
 		}
 	case reflect.Bool:
-		if val == "on" {
-			structField.SetBool(true)
-			break
-		}
-
 		if val == "" {
 			val = "false"
 		}
-		boolVal, err := strconv.ParseBool(val)
+		boolVal, err := profile.boolean(val)
 		if err != nil {
 			errors.Add([]string{nameInTag}, ERR_BOOLEAN_TYPE, "Value could not be parsed as boolean")
 		} else if boolVal {
@@ -744,8 +1415,10 @@ func setWithProperType(valueKind reflect.Kind, val string, structField reflect.V
 		if val == "" {
 			val = "0.0"
 		}
-		floatVal, err := strconv.ParseFloat(val, 32)
-		if err != nil {
+		floatVal, err := strconv.ParseFloat(profile.number(val), 32)
+		if isRangeError(err) {
+			errors.Add([]string{nameInTag}, ERR_OVERFLOW, "Value overflows float32")
+		} else if err != nil {
 			errors.Add([]string{nameInTag}, ERR_FLOAT_TYPE, "Value could not be parsed as 32-bit float")
 		} else {
 			structField.SetFloat(floatVal)
@@ -754,18 +1427,39 @@ func setWithProperType(valueKind reflect.Kind, val string, structField reflect.V
 		if val == "" {
 			val = "0.0"
 		}
-		floatVal, err := strconv.ParseFloat(val, 64)
-		if err != nil {
+		floatVal, err := strconv.ParseFloat(profile.number(val), 64)
+		if isRangeError(err) {
+			errors.Add([]string{nameInTag}, ERR_OVERFLOW, "Value overflows float64")
+		} else if err != nil {
 			errors.Add([]string{nameInTag}, ERR_FLOAT_TYPE, "Value could not be parsed as 64-bit float")
 		} else {
 			structField.SetFloat(floatVal)
 		}
+	case reflect.Complex64, reflect.Complex128:
+		if val == "" {
+			val = "0"
+		}
+		complexVal, err := strconv.ParseComplex(val, structField.Type().Bits())
+		if isRangeError(err) {
+			errors.Add([]string{nameInTag}, ERR_OVERFLOW, "Value overflows "+valueKind.String())
+		} else if err != nil {
+			errors.Add([]string{nameInTag}, ERR_COMPLEX_TYPE, "Value could not be parsed as complex number")
+		} else {
+			structField.SetComplex(complexVal)
+		}
 	case reflect.String:
 		structField.SetString(val)
 	}
 	return errors
 }
 
+// isRangeError reports whether a number could be parsed but is out of
+// range for its type.
+func isRangeError(err error) bool {
+	numErr, ok := err.(*strconv.NumError)
+	return ok && numErr.Err == strconv.ErrRange
+}
+
 // Don't pass in pointers to bind to. Can lead to bugs.
 func ensureNotPointer(obj interface{}) {
 	if reflect.TypeOf(obj).Kind() == reflect.Ptr {
@@ -777,7 +1471,61 @@ func ensureNotPointer(obj interface{}) {
 // with errors from deserialization, then maps both the
 // resulting struct and the errors to the context.
 func validateAndMap(obj reflect.Value, ctx *macaron.Context, errors Errors, ifacePtr ...interface{}) {
-	_, _ = ctx.Invoke(Validate(obj.Interface()))
+	mapValidated(obj, ctx, Validate(obj.Interface()), errors, ifacePtr...)
+}
+
+// validateFormAndMap works like validateAndMap, except that nested fields
+// are named by their full form path, e.g. "items[2].name", elements by the
+// index they were sent with as recorded in indices.
+func validateFormAndMap(obj reflect.Value, ctx *macaron.Context, errors Errors, indices formIndices, ifacePtr ...interface{}) {
+	mapValidated(obj, ctx, func(ctx *macaron.Context) {
+		_, _ = ctx.Invoke(validate(obj.Interface(), nestedFormPath))
+		errs := getErrors(ctx)
+		for i := range errs {
+			for j, name := range errs[i].FieldNames {
+				errs[i].FieldNames[j] = indices.sentPath(name)
+			}
+		}
+		ctx.Map(errs)
+	}, errors, ifacePtr...)
+}
+
+// formIndices holds the indices the elements of slices and arrays bound
+// from indexed keys were sent with, by the form path of the field.
+type formIndices map[string][]int
+
+// sentPath rewrites the element indices of a path, which count elements
+// in the model, e.g. "items[1].name", into the indices they were sent
+// with, e.g. "items[5].name".
+func (indices formIndices) sentPath(path string) string {
+	var sent strings.Builder
+	for {
+		open := strings.IndexByte(path, '[')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(path[open:], ']')
+		if end < 0 {
+			break
+		}
+		end += open
+
+		sent.WriteString(path[:open])
+		index := path[open+1 : end]
+		if n, err := strconv.Atoi(index); err == nil {
+			if elems := indices[sent.String()]; n >= 0 && n < len(elems) {
+				index = strconv.Itoa(elems[n])
+			}
+		}
+		sent.WriteString("[" + index + "]")
+		path = path[end+1:]
+	}
+	sent.WriteString(path)
+	return sent.String()
+}
+
+func mapValidated(obj reflect.Value, ctx *macaron.Context, validator macaron.Handler, errors Errors, ifacePtr ...interface{}) {
+	_, _ = ctx.Invoke(validator)
 	errors = append(errors, getErrors(ctx)...)
 	ctx.Map(errors)
 	ctx.Map(obj.Elem().Interface())
@@ -798,6 +1546,18 @@ type (
 		Error(*macaron.Context, Errors)
 	}
 
+	// StrictJsonModel can be implemented by a model to tell whether its
+	// JSON payloads are decoded strictly, whatever the Binder says.
+	StrictJsonModel interface {
+		StrictJson() bool
+	}
+
+	// StrictYamlModel can be implemented by a model to tell whether its
+	// YAML payloads are decoded strictly, whatever the Binder says.
+	StrictYamlModel interface {
+		StrictYaml() bool
+	}
+
 	// Validator is the interface that handles some rudimentary
 	// request validation logic so your application doesn't have to.
 	Validator interface {
@@ -0,0 +1,127 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"gopkg.in/macaron.v1"
+)
+
+type (
+	// limitedBody is a request body that fails with a bodyTooLargeError
+	// once more than limit bytes are read from it.
+	limitedBody struct {
+		io.ReadCloser
+		limit, remaining int64
+		err              *bodyTooLargeError
+	}
+
+	// bodyTooLargeError is reported for a request body beyond its limit.
+	bodyTooLargeError struct {
+		limit int64
+	}
+)
+
+func (e *bodyTooLargeError) Error() string {
+	return fmt.Sprintf("Request body is larger than %d bytes", e.limit)
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) <= b.remaining {
+		b.remaining -= int64(n)
+		return n, err
+	}
+
+	// A byte beyond the limit was read.
+	n, b.remaining = int(b.remaining), 0
+	b.err = &bodyTooLargeError{limit: b.limit}
+	return n, b.err
+}
+
+// MaxBodyBytes is middleware that limits the request body to n bytes for
+// the binding middleware coming after it, e.g. for a single route with
+// m.Post("/", binding.MaxBodyBytes(1<<20), binding.Json(Post{}), ...).
+// A body beyond the limit is reported as ERR_BODY_TOO_LARGE.
+func MaxBodyBytes(n int64) macaron.Handler {
+	return func(ctx *macaron.Context) {
+		limitBody(ctx, n)
+	}
+}
+
+// limitBody limits the request body to n bytes, unless n is not positive.
+func limitBody(ctx *macaron.Context, n int64) {
+	if n > 0 && ctx.Req.Request.Body != nil {
+		ctx.Req.Request.Body = &limitedBody{ReadCloser: ctx.Req.Request.Body, limit: n, remaining: n}
+	}
+}
+
+// tooLarge returns the error reading the request body failed with if it
+// went beyond its limit, whether err is or wraps it, or it was swallowed
+// on the way.
+func tooLarge(ctx *macaron.Context, err error) *bodyTooLargeError {
+	var e *bodyTooLargeError
+	if errors.As(err, &e) {
+		return e
+	}
+	if body, ok := ctx.Req.Request.Body.(*limitedBody); ok && body.err != nil {
+		return body.err
+	}
+	return nil
+}
+
+// hasReadError reports whether reading the request body failed because it
+// went beyond its limit or its encoding is not supported.
+func hasReadError(errors Errors) bool {
+	return errors.Has(ERR_BODY_TOO_LARGE) || errors.Has(ERR_CONTENT_ENCODING)
+}
+
+// mapUnvalidated maps the errors and the model without validating it, for
+// when the request body could not be read or decoded: a model missing its
+// body is not worth validating. The model is mapped all the same so that
+// handlers won't panic, but must hold nothing from the body.
+func mapUnvalidated(obj reflect.Value, ctx *macaron.Context, errors Errors, ifacePtr ...interface{}) {
+	ctx.Map(errors)
+	ctx.Map(obj.Elem().Interface())
+	if len(ifacePtr) > 0 {
+		ctx.MapTo(obj.Elem().Interface(), ifacePtr[0])
+	}
+}
+
+// addReadError adds an error that came up reading the request body, as
+// ERR_BODY_TOO_LARGE if the body went beyond its limit, as
+// ERR_CONTENT_ENCODING if its encoding is not supported, and else as
+// ERR_DESERIALIZATION.
+func addReadError(ctx *macaron.Context, errs Errors, err error) Errors {
+	var encodingErr *encodingError
+	if e := tooLarge(ctx, err); e != nil {
+		errs.Add([]string{}, ERR_BODY_TOO_LARGE, e.Error())
+	} else if errors.As(err, &encodingErr) {
+		errs.Add([]string{}, ERR_CONTENT_ENCODING, encodingErr.Error())
+	} else {
+		errs.Add([]string{}, ERR_DESERIALIZATION, err.Error())
+	}
+	return errs
+}
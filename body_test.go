@@ -0,0 +1,86 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/macaron.v1"
+)
+
+func Test_MaxBodyBytes(t *testing.T) {
+	Convey("Test request body limits", t, func() {
+		performBodyLimitTest := func(limit macaron.Handler, contentType, payload string, expectedCode int) {
+			m := macaron.Classic()
+			m.Post(testRoute, limit, Bind(Post{}), func(post Post) {
+				So(post.Title, ShouldEqual, "Glorious Post Title")
+			})
+
+			req, err := http.NewRequest("POST", testRoute, strings.NewReader(payload))
+			So(err, ShouldBeNil)
+			req.Header.Set("Content-Type", contentType)
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, expectedCode)
+			if expectedCode == http.StatusRequestEntityTooLarge {
+				So(resp.Body.String(), ShouldContainSubstring, `"classification":"BodyTooLargeError"`)
+				So(resp.Body.String(), ShouldNotContainSubstring, ERR_REQUIRED)
+			}
+		}
+
+		jsonPayload := `{"title": "Glorious Post Title", "content": "Lorem ipsum dolor sit amet"}`
+		formPayload := "title=Glorious+Post+Title&content=Lorem+ipsum+dolor+sit+amet"
+
+		Convey("Per route", func() {
+			performBodyLimitTest(MaxBodyBytes(int64(len(jsonPayload))), _JSON_CONTENT_TYPE, jsonPayload, http.StatusOK)
+			performBodyLimitTest(MaxBodyBytes(32), _JSON_CONTENT_TYPE, jsonPayload, http.StatusRequestEntityTooLarge)
+			performBodyLimitTest(MaxBodyBytes(32), _YAML_CONTENT_TYPE, "title: Glorious Post Title\ncontent: Lorem ipsum", http.StatusRequestEntityTooLarge)
+			performBodyLimitTest(MaxBodyBytes(32), formContentType, formPayload, http.StatusRequestEntityTooLarge)
+		})
+
+		Convey("Multipart forms", func() {
+			payload := "--b\r\nContent-Disposition: form-data; name=\"title\"\r\n\r\n" +
+				strings.Repeat("a", 200) + "\r\n--b--\r\n"
+			performBodyLimitTest(MaxBodyBytes(50), "multipart/form-data; boundary=b", payload, http.StatusRequestEntityTooLarge)
+
+			m := macaron.Classic()
+			m.Post(testRoute, MaxBodyBytes(50), MultipartForm(Post{}), func(post Post, errs Errors) {
+				So(post, ShouldResemble, Post{})
+				So(errs, ShouldHaveLength, 1)
+				So(errs[0].Classification, ShouldEqual, ERR_BODY_TOO_LARGE)
+			})
+			req, err := http.NewRequest("POST", testRoute, strings.NewReader(payload))
+			So(err, ShouldBeNil)
+			req.Header.Set("Content-Type", "multipart/form-data; boundary=b")
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+		})
+
+		Convey("Per binder", func() {
+			b := NewBinder()
+			b.SetMaxBodyBytes(32)
+			performBodyLimitTest(b.Handler(), _JSON_CONTENT_TYPE, jsonPayload, http.StatusRequestEntityTooLarge)
+			performBodyLimitTest(b.Handler(), formContentType, formPayload, http.StatusRequestEntityTooLarge)
+
+			b.SetMaxBodyBytes(0)
+			performBodyLimitTest(b.Handler(), formContentType, formPayload, http.StatusOK)
+		})
+	})
+}
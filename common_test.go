@@ -27,14 +27,14 @@ import (
 type (
 	// For basic test cases with a required field
 	Post struct {
-		Title   string `form:"title" json:"title" yaml:"title" binding:"Required"`
-		Content string `form:"content" json:"content" yaml:"content"`
+		Title   string `form:"title" json:"title" yaml:"title" xml:"title" binding:"Required"`
+		Content string `form:"content" json:"content" yaml:"content" xml:"content"`
 	}
 
 	// To be used as a nested struct (with a required field)
 	Person struct {
-		Name  string `form:"name" json:"name" yaml:"name" binding:"Required"`
-		Email string `form:"email" json:"email" yaml:"email"`
+		Name  string `form:"name" json:"name" yaml:"name" xml:"name" binding:"Required"`
+		Email string `form:"email" json:"email" yaml:"email" xml:"email"`
 	}
 
 	// For advanced test cases: multiple values, embedded
@@ -42,11 +42,11 @@ type (
 	// and multiple file uploads
 	BlogPost struct {
 		Post
-		Id          int     `binding:"Required"` // JSON and YAML not specified here for test coverage
-		Ignored     string  `form:"-" json:"-" yaml:"-"`
-		Ratings     []int   `form:"rating" json:"ratings" yaml:"ratings"`
-		Author      Person  `json:"author" yaml:"author"`
-		Coauthor    *Person `json:"coauthor" yaml:"coauthor"`
+		Id          int     `binding:"Required"` // JSON, YAML and XML not specified here for test coverage
+		Ignored     string  `form:"-" json:"-" yaml:"-" xml:"-"`
+		Ratings     []int   `form:"rating" json:"ratings" yaml:"ratings" xml:"ratings"`
+		Author      Person  `json:"author" yaml:"author" xml:"author"`
+		Coauthor    *Person `json:"coauthor" yaml:"coauthor" xml:"coauthor"`
 		HeaderImage *multipart.FileHeader
 		Pictures    []*multipart.FileHeader `form:"picture"`
 		unexported  string                  `form:"unexported"` //nolint
@@ -79,14 +79,20 @@ type (
 	}
 
 	Group struct {
-		Name   string   `json:"name" yaml:"name" binding:"Required"`
-		People []Person `json:"people" yaml:"people" binding:"MinSize(1)"`
+		Name   string   `json:"name" yaml:"name" xml:"name" binding:"Required"`
+		People []Person `json:"people" yaml:"people" xml:"people" binding:"MinSize(1)"`
 	}
 
 	UrlForm struct {
 		Url string `form:"Url" binding:"Url"`
 	}
 
+	Team struct {
+		Leads   [2]Person
+		Mascot  interface{}
+		Members []interface{}
+	}
+
 	CustomErrorHandle struct {
 		Rule `binding:"CustomRule"`
 	}
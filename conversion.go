@@ -0,0 +1,483 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"encoding"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ParseProfile tells how numbers and booleans are parsed from form, query,
+// URL parameter and header values. Values that are not valid as the profile
+// says are reported as they are otherwise, e.g. as ERR_FLOAT_TYPE.
+type ParseProfile struct {
+	// TrimSpace trims leading and trailing white space off values.
+	TrimSpace bool
+	// DecimalSeparator separates the fraction of a float, "." if empty.
+	DecimalSeparator string
+	// GroupSeparator, if set, may separate the digits of the integer part
+	// of a number into groups of three, e.g. 1,234,567.
+	GroupSeparator string
+	// Booleans maps words to the booleans they stand for, on top of those
+	// accepted by strconv.ParseBool.
+	Booleans map[string]bool
+	// FoldCase matches Booleans case-insensitively, e.g. "ON" as "on".
+	FoldCase bool
+}
+
+var (
+	// defaultProfile is used by Binders and fields that ask for no profile.
+	defaultProfile = &ParseProfile{
+		Booleans: map[string]bool{"on": true},
+	}
+
+	lenientBooleans = map[string]bool{
+		"on": true, "yes": true, "y": true,
+		"off": false, "no": false, "n": false,
+	}
+	germanBooleans = map[string]bool{
+		"on": true, "yes": true, "y": true, "ja": true, "j": true,
+		"off": false, "no": false, "n": false, "nein": false,
+	}
+)
+
+// number returns the number in Go syntax, or an empty string if it is not
+// valid as the profile says.
+func (p *ParseProfile) number(val string) string {
+	if p.TrimSpace {
+		val = strings.TrimSpace(val)
+	}
+
+	decimal := p.DecimalSeparator
+	if len(decimal) == 0 {
+		decimal = "."
+	}
+	intPart, fraction := val, ""
+	if i := strings.Index(val, decimal); i >= 0 {
+		intPart, fraction = val[:i], val[i+len(decimal):]
+	}
+
+	if len(p.GroupSeparator) > 0 {
+		if strings.Contains(fraction, p.GroupSeparator) {
+			return ""
+		}
+		if groups := strings.Split(intPart, p.GroupSeparator); len(groups) > 1 {
+			lead := strings.TrimLeft(groups[0], "+-")
+			if len(lead) == 0 || len(lead) > 3 || !isDigits(lead) {
+				return ""
+			}
+			for _, group := range groups[1:] {
+				if len(group) != 3 || !isDigits(group) {
+					return ""
+				}
+			}
+			intPart = strings.Join(groups, "")
+		}
+	}
+
+	if decimal != "." && strings.Contains(val, ".") && p.GroupSeparator != "." {
+		return ""
+	}
+	if len(fraction) > 0 || strings.Contains(val, decimal) {
+		return intPart + "." + fraction
+	}
+	return intPart
+}
+
+// boolean returns the boolean the value stands for as the profile says.
+func (p *ParseProfile) boolean(val string) (bool, error) {
+	if p.TrimSpace {
+		val = strings.TrimSpace(val)
+	}
+	if b, ok := p.Booleans[val]; ok {
+		return b, nil
+	}
+	if p.FoldCase {
+		for word, b := range p.Booleans {
+			if strings.EqualFold(word, val) {
+				return b, nil
+			}
+		}
+	}
+	return strconv.ParseBool(val)
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// collectionDelimiters maps the values of the collection_format tag to the
+// delimiter the elements of a slice are separated by within one value.
+var collectionDelimiters = map[string]string{
+	"csv":   ",",
+	"ssv":   " ",
+	"tsv":   "\t",
+	"pipes": "|",
+	"multi": "",
+}
+
+// setFormValues sets a struct field from the values found for it:
+// a slice or array field gets all of them, any other field the first one.
+// With a collection_format tag, the values of a slice field are split
+// into its elements as well, and element errors are named by index.
+func (b *Binder) setFormValues(structField reflect.Value, tag reflect.StructTag, values []string, nameInTag string, errors Errors) Errors {
+	numElems := len(values)
+	if numElems == 0 {
+		return errors
+	}
+
+	if structField.CanAddr() {
+		if opt, ok := structField.Addr().Interface().(settableOptional); ok {
+			return b.setOptional(opt, tag, values, nameInTag, errors)
+		}
+	}
+	if b.isMultiValue(structField.Type()) {
+		format, indexed := tag.Lookup("collection_format")
+		if indexed {
+			if values = splitCollection(format, values); len(values) == 0 {
+				return errors
+			}
+			numElems = len(values)
+		}
+
+		elems, ok := makeElems(structField.Type(), numElems, nameInTag, &errors)
+		if !ok {
+			return errors
+		}
+		for i := 0; i < numElems; i++ {
+			name := nameInTag
+			if indexed {
+				name = fmt.Sprintf("%s[%d]", nameInTag, i)
+			}
+			errors = b.setFormValue(elems.Index(i), tag, values[i], name, errors)
+		}
+		structField.Set(elems)
+	} else {
+		errors = b.setFormValue(structField, tag, values[0], nameInTag, errors)
+	}
+	return errors
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	bigIntType          = reflect.TypeOf(big.Int{})
+	bigFloatType        = reflect.TypeOf(big.Float{})
+)
+
+// makeElems returns a slice or an array of the type to hold the given
+// number of elements. An array must be of that length, or else an
+// ERR_ARRAY_LENGTH error is added.
+func makeElems(typ reflect.Type, numElems int, nameInTag string, errors *Errors) (reflect.Value, bool) {
+	if typ.Kind() != reflect.Array {
+		return reflect.MakeSlice(typ, numElems, numElems), true
+	}
+	if typ.Len() != numElems {
+		errors.Add([]string{nameInTag}, ERR_ARRAY_LENGTH, fmt.Sprintf("Expected %d values, got %d", typ.Len(), numElems))
+		return reflect.Value{}, false
+	}
+	return reflect.New(typ).Elem(), true
+}
+
+// splitCollection splits the values into the elements of a slice as the
+// collection format says. An empty value has no elements.
+func splitCollection(format string, values []string) []string {
+	delim, ok := collectionDelimiters[format]
+	if !ok {
+		panic("binding: unknown collection_format " + format)
+	}
+	if len(delim) == 0 {
+		return values
+	}
+
+	var elems []string
+	for _, v := range values {
+		if len(v) > 0 {
+			elems = append(elems, strings.Split(v, delim)...)
+		}
+	}
+	return elems
+}
+
+// isTextValue reports whether values of the type are converted from a
+// single string as a whole, rather than field by field or element by
+// element.
+func (b *Binder) isTextValue(typ reflect.Type) bool {
+	return b.converter(typ) != nil || typ.Implements(optionalType) ||
+		typ == timeType || typ == durationType || reflect.PtrTo(typ).Implements(textUnmarshalerType)
+}
+
+// isMultiValue reports whether a field of the type, a slice or an array,
+// takes every value found for it rather than the first one.
+func (b *Binder) isMultiValue(typ reflect.Type) bool {
+	return (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) && !b.isTextValue(typ)
+}
+
+// setFormValue sets a value from its string form: a type with a
+// registered Converter is converted by it, a pointer or an Optional is
+// set to a new value converted from it, a time.Time is parsed as laid
+// out by the time_format tag of its field, a time.Duration by
+// time.ParseDuration, a big.Int or big.Float by its SetString method, a
+// type implementing encoding.TextUnmarshaler by its UnmarshalText method,
+// and any other type by setWithProperType. Integers are parsed in the base
+// given by the form_base tag of their field, numbers and booleans as the
+// parsing profile given by its form_profile tag or used by the Binder says.
+func (b *Binder) setFormValue(value reflect.Value, tag reflect.StructTag, val string, nameInTag string, errors Errors) Errors {
+	if convert := b.converter(value.Type()); convert != nil {
+		return setConverted(value, convert, val, nameInTag, errors)
+	}
+
+	if value.CanAddr() {
+		if opt, ok := value.Addr().Interface().(settableOptional); ok {
+			return b.setOptional(opt, tag, []string{val}, nameInTag, errors)
+		}
+	}
+
+	// A pointer is only allocated for a value that is present, so that
+	// absent and zero values can be told apart.
+	if value.Kind() == reflect.Ptr && (value.Type().Elem().Kind() != reflect.Struct || b.isTextValue(value.Type().Elem())) {
+		ptr := reflect.New(value.Type().Elem())
+		numErrors := len(errors)
+		if errors = b.setFormValue(ptr.Elem(), tag, val, nameInTag, errors); len(errors) == numErrors {
+			value.Set(ptr)
+		}
+		return errors
+	}
+
+	switch {
+	case value.Type() == timeType:
+		t, err := parseTime(tag, val)
+		if err != nil {
+			errors.Add([]string{nameInTag}, ERR_TIME_TYPE, "Value could not be parsed as time")
+		} else {
+			value.Set(reflect.ValueOf(t))
+		}
+	case value.Type() == durationType:
+		if val == "" {
+			val = "0"
+		}
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			errors.Add([]string{nameInTag}, ERR_DURATION_TYPE, "Value could not be parsed as duration")
+		} else {
+			value.SetInt(int64(d))
+		}
+	case value.Type() == bigIntType:
+		if val == "" {
+			val = "0"
+		}
+		if n, ok := new(big.Int).SetString(val, numberBase(tag)); !ok {
+			errors.Add([]string{nameInTag}, ERR_INTERGER_TYPE, "Value could not be parsed as integer")
+		} else {
+			value.Set(reflect.ValueOf(n).Elem())
+		}
+	case value.Type() == bigFloatType:
+		if val == "" {
+			val = "0"
+		}
+		if f, ok := new(big.Float).SetString(val); !ok {
+			errors.Add([]string{nameInTag}, ERR_FLOAT_TYPE, "Value could not be parsed as float")
+		} else {
+			value.Set(reflect.ValueOf(f).Elem())
+		}
+	case value.CanAddr() && value.Addr().Type().Implements(textUnmarshalerType):
+		if err := value.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(val)); err != nil {
+			errors.Add([]string{nameInTag}, ERR_TEXT_TYPE, "Value could not be parsed as "+value.Type().String())
+		}
+	default:
+		errors = setWithProperType(value.Kind(), val, value, b.profileOf(tag), numberBase(tag), nameInTag, errors)
+	}
+	return errors
+}
+
+// parseTime parses a time as configured by the tags of its field:
+// time_format gives the layout, RFC 3339 by default, or one of "unix",
+// "unixmilli" and "unixnano" for a number of seconds, milliseconds or
+// nanoseconds since the epoch; time_location gives the location of
+// times without a zone, UTC by default; time_utc:"true" converts
+// the result to UTC. An empty value gives the zero time.
+func parseTime(tag reflect.StructTag, val string) (time.Time, error) {
+	if val == "" {
+		return time.Time{}, nil
+	}
+
+	loc := time.UTC
+	if name := tag.Get("time_location"); len(name) > 0 {
+		var err error
+		if loc, err = loadLocation(name); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	var t time.Time
+	switch format := tag.Get("time_format"); format {
+	case "unix", "unixmilli", "unixnano":
+		n, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return t, err
+		}
+		switch format {
+		case "unix":
+			t = time.Unix(n, 0)
+		case "unixmilli":
+			t = time.Unix(0, n*int64(time.Millisecond))
+		default:
+			t = time.Unix(0, n)
+		}
+		t = t.In(loc)
+	default:
+		if len(format) == 0 {
+			format = time.RFC3339
+		}
+		var err error
+		if t, err = time.ParseInLocation(format, val, loc); err != nil {
+			return t, err
+		}
+	}
+
+	if utc, _ := strconv.ParseBool(tag.Get("time_utc")); utc {
+		t = t.UTC()
+	}
+	return t, nil
+}
+
+// numberBase returns the base integers are parsed in as given by the
+// form_base tag, named so as not to clash with the tags of other packages:
+// 10 by default, and 0 to accept the prefixes and underscores of Go
+// integer literals, e.g. 0x1F, 0b101 or 1_000.
+func numberBase(tag reflect.StructTag) int {
+	value, ok := tag.Lookup("form_base")
+	if !ok {
+		return 10
+	}
+	base, err := strconv.Atoi(value)
+	if err != nil || base == 1 || base < 0 || base > 36 {
+		panic("binding: invalid base " + value)
+	}
+	return base
+}
+
+// setConverted sets a value to what the converter returns for val.
+func setConverted(value reflect.Value, convert Converter, val string, nameInTag string, errors Errors) Errors {
+	v, err := convert(val)
+	if err != nil {
+		errors.Add([]string{nameInTag}, ERR_CONVERSION, err.Error())
+		return errors
+	}
+
+	if v == nil {
+		value.Set(reflect.Zero(value.Type()))
+	} else if rv := reflect.ValueOf(v); rv.Type().AssignableTo(value.Type()) {
+		value.Set(rv)
+	} else {
+		errors.Add([]string{nameInTag}, ERR_CONVERSION, "Converter returned "+rv.Type().String()+" for "+value.Type().String())
+	}
+	return errors
+}
+
+// setOptional sets an Optional from the values found for it: a single
+// empty value sets it to null, and any other values to a value.
+func (b *Binder) setOptional(opt settableOptional, tag reflect.StructTag, values []string, nameInTag string, errors Errors) Errors {
+	if len(values) == 1 && len(values[0]) == 0 {
+		opt.setNull()
+		return errors
+	}
+
+	numErrors := len(errors)
+	if errors = b.setFormValues(reflect.ValueOf(opt.valuePtr()).Elem(), tag, values, nameInTag, errors); len(errors) == numErrors {
+		opt.setPresent()
+	}
+	return errors
+}
+
+// locations caches the locations named by time_location tags, so that
+// they are not loaded again for every value.
+var locations sync.Map
+
+// loadLocation returns the location with the given name, loading it
+// only the first time it is asked for.
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// checkedTypes holds the model types checkTags has already gone through.
+var checkedTypes sync.Map
+
+// checkTags panics on a conversion tag of the model, or of any struct it
+// holds, that cannot be made sense of, so that a typo fails when the
+// middleware is built rather than on every request.
+func checkTags(typ reflect.Type) {
+	if _, ok := checkedTypes.Load(typ); ok {
+		return
+	}
+	checkTagsOf(typ, map[reflect.Type]bool{})
+	checkedTypes.Store(typ, true)
+}
+
+func checkTagsOf(typ reflect.Type, seen map[reflect.Type]bool) {
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice ||
+		typ.Kind() == reflect.Array || typ.Kind() == reflect.Map {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct || seen[typ] {
+		return
+	}
+	seen[typ] = true
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		checkFieldTags(field)
+		checkTagsOf(field.Type, seen)
+	}
+}
+
+// checkFieldTags panics on a conversion tag of the field with a value
+// that is not valid.
+func checkFieldTags(field reflect.StructField) {
+	if name := field.Tag.Get("time_location"); len(name) > 0 {
+		if _, err := loadLocation(name); err != nil {
+			panic("binding: invalid time_location " + name + " of field " + field.Name + ": " + err.Error())
+		}
+	}
+	if format, ok := field.Tag.Lookup("collection_format"); ok {
+		if _, ok := collectionDelimiters[format]; !ok {
+			panic("binding: unknown collection_format " + format + " of field " + field.Name)
+		}
+	}
+	if name, ok := field.Tag.Lookup("form_profile"); ok {
+		lookupProfile(name)
+	}
+	numberBase(field.Tag)
+}
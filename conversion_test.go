@@ -0,0 +1,194 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/macaron.v1"
+)
+
+type (
+	level int
+
+	scheduleForm struct {
+		Start   time.Time     `form:"start"`
+		Day     time.Time     `form:"day" time_format:"2006-01-02" time_location:"Europe/Berlin"`
+		DayUTC  time.Time     `form:"day_utc" time_format:"2006-01-02" time_location:"Europe/Berlin" time_utc:"true"`
+		Stamp   time.Time     `form:"stamp" time_format:"unix" time_utc:"1"`
+		Timeout time.Duration `form:"timeout"`
+		Addr    net.IP        `form:"addr"`
+		Levels  []level       `form:"level"`
+	}
+
+	numberForm struct {
+		Small   int8       `form:"small"`
+		Port    uint16     `form:"port"`
+		Mask    uint32     `form:"mask" form_base:"0"`
+		Color   int        `form:"color" form_base:"16"`
+		Ratio   float32    `form:"ratio"`
+		Signal  complex128 `form:"signal"`
+		Supply  *big.Int   `form:"supply" form_base:"0"`
+		Precise big.Float  `form:"precise"`
+	}
+)
+
+func (l *level) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return errors.New("unknown level")
+	}
+	return nil
+}
+
+func Test_TextConversion(t *testing.T) {
+	Convey("Test conversion of times, durations and text values", t, func() {
+		performConversionTest := func(query string, check func(actual scheduleForm, errs Errors)) {
+			m := macaron.Classic()
+			m.Get(testRoute, Form(scheduleForm{}), check)
+
+			req, err := http.NewRequest("GET", testRoute+query, nil)
+			So(err, ShouldBeNil)
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+		}
+
+		Convey("Happy path", func() {
+			performConversionTest("?start=2021-03-04T05:06:07%2B01:00&day=2021-03-04&day_utc=2021-03-04&stamp=1614834367&timeout=1m30s&addr=192.168.0.1&level=low&level=high",
+				func(actual scheduleForm, errs Errors) {
+					berlin, err := time.LoadLocation("Europe/Berlin")
+					So(err, ShouldBeNil)
+
+					So(errs, ShouldBeEmpty)
+					So(actual.Start.Equal(time.Date(2021, 3, 4, 4, 6, 7, 0, time.UTC)), ShouldBeTrue)
+					So(actual.Day.String(), ShouldEqual, time.Date(2021, 3, 4, 0, 0, 0, 0, berlin).String())
+					So(actual.DayUTC.String(), ShouldEqual, "2021-03-03 23:00:00 +0000 UTC")
+					So(actual.Stamp.String(), ShouldEqual, "2021-03-04 05:06:07 +0000 UTC")
+					So(actual.Timeout, ShouldEqual, 90*time.Second)
+					So(actual.Addr.String(), ShouldEqual, "192.168.0.1")
+					So(actual.Levels, ShouldResemble, []level{1, 2})
+				})
+		})
+
+		Convey("Conversion errors", func() {
+			performConversionTest("?start=yesterday&day=04.03.2021&stamp=now&timeout=forever&addr=localhost&level=medium",
+				func(actual scheduleForm, errs Errors) {
+					So(actual.Start.IsZero(), ShouldBeTrue)
+					So(errs, ShouldResemble, Errors{
+						{FieldNames: []string{"start"}, Classification: ERR_TIME_TYPE, Message: "Value could not be parsed as time"},
+						{FieldNames: []string{"day"}, Classification: ERR_TIME_TYPE, Message: "Value could not be parsed as time"},
+						{FieldNames: []string{"stamp"}, Classification: ERR_TIME_TYPE, Message: "Value could not be parsed as time"},
+						{FieldNames: []string{"timeout"}, Classification: ERR_DURATION_TYPE, Message: "Value could not be parsed as duration"},
+						{FieldNames: []string{"addr"}, Classification: ERR_TEXT_TYPE, Message: "Value could not be parsed as net.IP"},
+						{FieldNames: []string{"level"}, Classification: ERR_TEXT_TYPE, Message: "Value could not be parsed as binding.level"},
+					})
+				})
+		})
+
+		Convey("Invalid locations fail when the middleware is built", func() {
+			type event struct {
+				At time.Time `form:"at" time_location:"Mars/Olympus_Mons"`
+			}
+			So(func() { Form(event{}) }, ShouldPanic)
+			So(func() { Request(struct{ Events []event }{}) }, ShouldPanic)
+		})
+	})
+}
+
+func Test_NumericConversion(t *testing.T) {
+	Convey("Test conversion of numbers", t, func() {
+		performNumericTest := func(query string, check func(actual numberForm, errs Errors)) {
+			m := macaron.Classic()
+			m.Get(testRoute, Form(numberForm{}), check)
+
+			req, err := http.NewRequest("GET", testRoute+query, nil)
+			So(err, ShouldBeNil)
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+		}
+
+		Convey("Happy path", func() {
+			performNumericTest("?small=-128&port=65535&mask=0xFF_FF&color=1f&ratio=0.5&signal=1%2B2i&supply=0b1_0000000000000000000000000000000000000000000000000000000000000000&precise=3.25",
+				func(actual numberForm, errs Errors) {
+					So(errs, ShouldBeEmpty)
+					So(actual.Small, ShouldEqual, -128)
+					So(actual.Port, ShouldEqual, 65535)
+					So(actual.Mask, ShouldEqual, 0xFFFF)
+					So(actual.Color, ShouldEqual, 0x1F)
+					So(actual.Ratio, ShouldEqual, 0.5)
+					So(actual.Signal, ShouldEqual, complex(1, 2))
+					So(actual.Supply.String(), ShouldEqual, "18446744073709551616")
+					So(actual.Precise.String(), ShouldEqual, "3.25")
+				})
+		})
+
+		Convey("Overflow and parse errors", func() {
+			performNumericTest("?small=300&port=-1&mask=0x1_0000_0000&color=0x1f&ratio=1e39&signal=1e309&supply=lots&precise=pi",
+				func(actual numberForm, errs Errors) {
+					So(actual.Small, ShouldEqual, 0)
+					So(actual.Supply, ShouldBeNil)
+					So(errs, ShouldResemble, Errors{
+						{FieldNames: []string{"small"}, Classification: ERR_OVERFLOW, Message: "Value overflows int8"},
+						{FieldNames: []string{"port"}, Classification: ERR_INTERGER_TYPE, Message: "Value could not be parsed as unsigned integer"},
+						{FieldNames: []string{"mask"}, Classification: ERR_OVERFLOW, Message: "Value overflows uint32"},
+						{FieldNames: []string{"color"}, Classification: ERR_INTERGER_TYPE, Message: "Value could not be parsed as integer"},
+						{FieldNames: []string{"ratio"}, Classification: ERR_OVERFLOW, Message: "Value overflows float32"},
+						{FieldNames: []string{"signal"}, Classification: ERR_OVERFLOW, Message: "Value overflows complex128"},
+						{FieldNames: []string{"supply"}, Classification: ERR_INTERGER_TYPE, Message: "Value could not be parsed as integer"},
+						{FieldNames: []string{"precise"}, Classification: ERR_FLOAT_TYPE, Message: "Value could not be parsed as float"},
+					})
+				})
+		})
+
+		Convey("Bases are opt-in", func() {
+			performNumericTest("?small=0x10&port=1_000",
+				func(actual numberForm, errs Errors) {
+					So(errs, ShouldResemble, Errors{
+						{FieldNames: []string{"small"}, Classification: ERR_INTERGER_TYPE, Message: "Value could not be parsed as integer"},
+						{FieldNames: []string{"port"}, Classification: ERR_INTERGER_TYPE, Message: "Value could not be parsed as unsigned integer"},
+					})
+				})
+		})
+
+		Convey("Invalid bases fail when the middleware is built", func() {
+			So(func() {
+				Header(struct {
+					Mask uint32 `header:"X-Mask" form_base:"hex"`
+				}{})
+			}, ShouldPanic)
+		})
+
+		Convey("Base tags of other packages are left alone", func() {
+			So(func() {
+				Bind(struct {
+					Mask uint32 `json:"mask" base:"hex"`
+				}{})
+			}, ShouldNotPanic)
+		})
+	})
+}
@@ -0,0 +1,97 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/macaron.v1"
+)
+
+type preferenceCookies struct {
+	Session  string   `cookie:"session_id" binding:"Required"`
+	PageSize int      `cookie:"page_size" binding:"Range(1,100)"`
+	Theme    string   `cookie:"theme" binding:"In(light,dark)"`
+	Tracking []string `cookie:"tracking"`
+	Title    string
+}
+
+var cookieTestCases = []cookieTestCase{
+	{
+		description: "Happy path",
+		cookie:      "session_id=abc; page_size=20; theme=dark; tracking=a; tracking=b; Title=ignored",
+		expected:    preferenceCookies{Session: "abc", PageSize: 20, Theme: "dark", Tracking: []string{"a", "b"}},
+	},
+	{
+		description: "Missing required cookie",
+		cookie:      "page_size=20; theme=light",
+		expected:    preferenceCookies{PageSize: 20, Theme: "light"},
+		expectedErrors: Errors{
+			{FieldNames: []string{"session_id"}, Classification: ERR_REQUIRED, Message: "Required"},
+		},
+	},
+	{
+		description: "Conversion and validation errors",
+		cookie:      "session_id=abc; page_size=twenty; theme=blue",
+		expected:    preferenceCookies{Session: "abc", Theme: "blue"},
+		expectedErrors: Errors{
+			{FieldNames: []string{"page_size"}, Classification: ERR_INTERGER_TYPE, Message: "Value could not be parsed as integer"},
+			{FieldNames: []string{"page_size"}, Classification: ERR_RANGE, Message: "Range"},
+			{FieldNames: []string{"theme"}, Classification: ERR_IN, Message: "In"},
+		},
+	},
+}
+
+func Test_Cookie(t *testing.T) {
+	Convey("Test cookie binding", t, func() {
+		for _, testCase := range cookieTestCases {
+			Convey(testCase.description, func() {
+				performCookieTest(testCase)
+			})
+		}
+	})
+}
+
+func performCookieTest(testCase cookieTestCase) {
+	m := macaron.Classic()
+	m.Get(testRoute, Cookie(preferenceCookies{}), func(actual preferenceCookies, errs Errors) {
+		So(actual, ShouldResemble, testCase.expected)
+		if len(testCase.expectedErrors) == 0 {
+			So(errs, ShouldBeEmpty)
+		} else {
+			So(errs, ShouldResemble, testCase.expectedErrors)
+		}
+	})
+
+	req, err := http.NewRequest("GET", testRoute, nil)
+	So(err, ShouldBeNil)
+	req.Header.Set("Cookie", testCase.cookie)
+
+	resp := httptest.NewRecorder()
+	m.ServeHTTP(resp, req)
+	So(resp.Code, ShouldEqual, http.StatusOK)
+}
+
+type (
+	cookieTestCase struct {
+		description    string
+		cookie         string
+		expected       preferenceCookies
+		expectedErrors Errors
+	}
+)
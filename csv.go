@@ -0,0 +1,220 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"mime"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/macaron.v1"
+)
+
+// Csv is middleware to deserialize a CSV document from the request into
+// the slice of structs that is passed in, one element per record. The
+// first record is the header, whose columns are matched to struct fields
+// the same way Form matches keys: by form tag, or else by name mapper.
+// The document is read from the request body or, for a multipart form,
+// from the uploaded file (the first one by field name, if there are more).
+// Each row is validated on its own. Errors name fields by their column,
+// prefixed with the index of the row, e.g. "[2].name", and carry its line
+// in Line.
+// An interface pointer can be added as a second argument in order
+// to map the slice to a specific interface.
+func Csv(csvSlice interface{}, ifacePtr ...interface{}) macaron.Handler {
+	ensureNotPointer(csvSlice)
+	typ := reflect.TypeOf(csvSlice)
+	if !isCsvModel(typ) {
+		panic("Csv models must be slices of structs")
+	}
+	checkTags(typ)
+
+	return func(ctx *macaron.Context) {
+		var errors Errors
+		b := binderOf(ctx)
+		csvSlice := reflect.New(typ)
+
+		body, err := csvBody(ctx)
+		if err != nil {
+			errors = addReadError(ctx, errors, err)
+			if hasReadError(errors) {
+				mapUnvalidated(csvSlice, ctx, errors, ifacePtr...)
+				return
+			}
+		} else if body != nil {
+			defer body.Close()
+			errors = b.mapCsv(ctx, csvSlice.Elem(), csv.NewReader(body), errors)
+		}
+
+		ctx.Map(errors)
+		ctx.Map(csvSlice.Elem().Interface())
+		if len(ifacePtr) > 0 {
+			ctx.MapTo(csvSlice.Elem().Interface(), ifacePtr[0])
+		}
+	}
+}
+
+// csvBinder is what Bind dispatches CSV documents to. Since Bind serves
+// any model, a model that is not a slice of structs is told that the
+// Content-Type is not supported instead.
+func csvBinder(obj interface{}, ifacePtr ...interface{}) macaron.Handler {
+	ensureNotPointer(obj)
+	if isCsvModel(reflect.TypeOf(obj)) {
+		return Csv(obj, ifacePtr...)
+	}
+	return func(ctx *macaron.Context) {
+		var errors Errors
+		errors.Add([]string{}, ERR_CONTENT_TYPE, "Unsupported Content-Type")
+		ctx.Map(errors)
+		ctx.Map(obj)
+	}
+}
+
+// isCsvModel reports whether the type is a slice of structs or of
+// pointers to structs.
+func isCsvModel(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Slice {
+		return false
+	}
+	elem := typ.Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	return elem.Kind() == reflect.Struct
+}
+
+// csvBody returns the reader of the CSV document carried by the request,
+// or nil if there is none.
+func csvBody(ctx *macaron.Context) (io.ReadCloser, error) {
+	if err := decompressBody(ctx); err != nil {
+		return nil, err
+	}
+	mediaType, _, _ := mime.ParseMediaType(ctx.Req.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return ctx.Req.Request.Body, nil
+	}
+
+	if err := ctx.Req.ParseMultipartForm(MaxMemory); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(ctx.Req.MultipartForm.File))
+	for name := range ctx.Req.MultipartForm.File {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if files := ctx.Req.MultipartForm.File[name]; len(files) > 0 {
+			return files[0].Open()
+		}
+	}
+	return nil, nil
+}
+
+// mapCsv appends one element to the slice per CSV record, mapping cells
+// to fields with mapForm, and validates each of them.
+func (b *Binder) mapCsv(ctx *macaron.Context, slice reflect.Value, r *csv.Reader, errors Errors) Errors {
+	header, err := r.Read()
+	if err == io.EOF {
+		return errors
+	} else if err != nil {
+		return addCsvError(ctx, errors, err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	header[0] = strings.TrimPrefix(header[0], "\ufeff") // Byte order mark.
+
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+
+	for row := 0; ; row++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil && !isFieldCountError(err) {
+			return addCsvError(ctx, errors, err)
+		}
+		line, _ := r.FieldPos(0)
+
+		var rowErrors Errors
+		if err != nil {
+			rowErrors.Add([]string{}, ERR_DESERIALIZATION, "wrong number of fields")
+		}
+
+		form := make(map[string][]string, len(header))
+		for i, cell := range record {
+			if i < len(header) {
+				form[header[i]] = append(form[header[i]], cell)
+			}
+		}
+
+		elem := reflect.New(elemType)
+		rowErrors = b.mapForm(elem, form, nil, rowErrors)
+		rowErrors = b.validateStructAt(rowErrors, elem.Interface(), "", formFieldPath)
+		if validator, ok := elem.Interface().(Validator); ok {
+			rowErrors = validator.Validate(ctx, rowErrors)
+		}
+
+		prefix := fmt.Sprintf("[%d]", row)
+		for _, e := range rowErrors {
+			if len(e.FieldNames) == 0 {
+				e.FieldNames = []string{prefix}
+			} else {
+				fieldNames := make([]string, len(e.FieldNames))
+				for i := range e.FieldNames {
+					fieldNames[i] = prefix + "." + e.FieldNames[i]
+				}
+				e.FieldNames = fieldNames
+			}
+			if e.Line == 0 {
+				e.Line = line
+			}
+			errors = append(errors, e)
+		}
+
+		if isPtr {
+			slice.Set(reflect.Append(slice, elem))
+		} else {
+			slice.Set(reflect.Append(slice, elem.Elem()))
+		}
+	}
+	return errors
+}
+
+func isFieldCountError(err error) bool {
+	perr, ok := err.(*csv.ParseError)
+	return ok && perr.Err == csv.ErrFieldCount
+}
+
+func addCsvError(ctx *macaron.Context, errors Errors, err error) Errors {
+	perr, ok := err.(*csv.ParseError)
+	if !ok {
+		return addReadError(ctx, errors, err)
+	}
+	return append(errors, Error{
+		FieldNames:     []string{},
+		Classification: ERR_DESERIALIZATION,
+		Message:        err.Error(),
+		Line:           perr.Line,
+		Column:         perr.Column,
+	})
+}
@@ -0,0 +1,188 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/macaron.v1"
+)
+
+type csvItem struct {
+	Sku       string `form:"sku" binding:"Required"`
+	Name      string `form:"name" binding:"MaxSize(10)"`
+	Quantity  int    `form:"qty"`
+	UnitPrice float64
+}
+
+var csvTestCases = []csvTestCase{
+	{
+		description: "Happy path",
+		payload: "sku,name,qty,unit_price\n" +
+			"A-1,Widget,3,1.5\n" +
+			"B-2,Gadget,,0.25\n",
+		expected: []csvItem{
+			{Sku: "A-1", Name: "Widget", Quantity: 3, UnitPrice: 1.5},
+			{Sku: "B-2", Name: "Gadget", UnitPrice: 0.25},
+		},
+	},
+	{
+		description: "Byte order mark and unknown columns",
+		payload:     "\ufeffsku, color ,name\nA-1,red,Widget\n",
+		expected:    []csvItem{{Sku: "A-1", Name: "Widget"}},
+	},
+	{
+		description: "Empty payload",
+		payload:     "",
+		expected:    []csvItem(nil),
+	},
+	{
+		description: "Conversion and validation errors carry the row",
+		payload: "sku,name,qty\n" +
+			"A-1,Widget,3\n" +
+			"B-2,Gadget,many\n" +
+			",Thingamajig,1\n",
+		expected: []csvItem{
+			{Sku: "A-1", Name: "Widget", Quantity: 3},
+			{Sku: "B-2", Name: "Gadget"},
+			{Name: "Thingamajig", Quantity: 1},
+		},
+		expectedErrors: Errors{
+			{FieldNames: []string{"[1].qty"}, Classification: ERR_INTERGER_TYPE, Message: "Value could not be parsed as integer", Line: 3},
+			{FieldNames: []string{"[2].sku"}, Classification: ERR_REQUIRED, Message: "Required", Line: 4},
+			{FieldNames: []string{"[2].name"}, Classification: ERR_MAX_SIZE, Message: "MaxSize", Line: 4},
+		},
+	},
+	{
+		description: "Wrong number of fields",
+		payload:     "sku,name\nA-1\nB-2,Gadget\n",
+		expected:    []csvItem{{Sku: "A-1"}, {Sku: "B-2", Name: "Gadget"}},
+		expectedErrors: Errors{
+			{FieldNames: []string{"[0]"}, Classification: ERR_DESERIALIZATION, Message: "wrong number of fields", Line: 2},
+		},
+	},
+	{
+		description: "Malformed CSV",
+		payload:     "sku,name\nA-1,\"Widget\n",
+		expected:    []csvItem(nil),
+		expectedErrors: Errors{
+			{FieldNames: []string{}, Classification: ERR_DESERIALIZATION, Message: "parse error on line 2, column 13: extraneous or missing \" in quoted-field", Line: 2, Column: 13},
+		},
+	},
+}
+
+func Test_Csv(t *testing.T) {
+	Convey("Test CSV", t, func() {
+		for _, testCase := range csvTestCases {
+			Convey(testCase.description, func() {
+				Convey("From the body", func() {
+					performCsvTest(Csv, testCase, false)
+					performCsvTest(BindIgnErr, testCase, false)
+				})
+
+				Convey("From a multipart file", func() {
+					performCsvTest(Csv, testCase, true)
+				})
+			})
+		}
+
+		Convey("Pointer elements", func() {
+			m := macaron.Classic()
+			m.Post(testRoute, Csv([]*csvItem{}), func(actual []*csvItem, errs Errors) {
+				So(errs, ShouldBeEmpty)
+				So(actual, ShouldResemble, []*csvItem{{Sku: "A-1", Name: "Widget"}})
+			})
+
+			req, err := http.NewRequest("POST", testRoute, strings.NewReader("sku,name\nA-1,Widget\n"))
+			So(err, ShouldBeNil)
+			req.Header.Set("Content-Type", _CSV_CONTENT_TYPE)
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+		})
+
+		Convey("Models must be slices of structs", func() {
+			So(func() { Csv(csvItem{}) }, ShouldPanic)
+			So(func() { Csv([]string{}) }, ShouldPanic)
+		})
+
+		Convey("Bind with a model that is not a slice", func() {
+			m := macaron.Classic()
+			m.Post(testRoute, Bind(csvItem{}), func(actual csvItem, errs Errors) {
+				So(actual, ShouldResemble, csvItem{})
+				So(errs, ShouldResemble, Errors{
+					{FieldNames: []string{}, Classification: ERR_CONTENT_TYPE, Message: "Unsupported Content-Type"},
+				})
+			})
+
+			req, err := http.NewRequest("POST", testRoute, strings.NewReader("sku,name\nA-1,Widget\n"))
+			So(err, ShouldBeNil)
+			req.Header.Set("Content-Type", _CSV_CONTENT_TYPE)
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusUnsupportedMediaType)
+		})
+	})
+}
+
+func performCsvTest(binder handlerFunc, testCase csvTestCase, multipartFile bool) {
+	m := macaron.Classic()
+	m.Post(testRoute, binder([]csvItem{}), func(actual []csvItem, errs Errors) {
+		So(actual, ShouldResemble, testCase.expected)
+		if len(testCase.expectedErrors) == 0 {
+			So(errs, ShouldBeEmpty)
+		} else {
+			So(errs, ShouldResemble, testCase.expectedErrors)
+		}
+	})
+
+	body := &bytes.Buffer{}
+	contentType := _CSV_CONTENT_TYPE
+	if multipartFile {
+		w := multipart.NewWriter(body)
+		So(w.WriteField("comment", "ignored"), ShouldBeNil)
+		fw, err := w.CreateFormFile("upload", "items.csv")
+		So(err, ShouldBeNil)
+		_, err = fw.Write([]byte(testCase.payload))
+		So(err, ShouldBeNil)
+		So(w.Close(), ShouldBeNil)
+		contentType = w.FormDataContentType()
+	} else {
+		body.WriteString(testCase.payload)
+	}
+
+	req, err := http.NewRequest("POST", testRoute, body)
+	So(err, ShouldBeNil)
+	req.Header.Set("Content-Type", contentType)
+
+	resp := httptest.NewRecorder()
+	m.ServeHTTP(resp, req)
+	So(resp.Code, ShouldEqual, http.StatusOK)
+}
+
+type (
+	csvTestCase struct {
+		description    string
+		payload        string
+		expected       interface{}
+		expectedErrors Errors
+	}
+)
@@ -0,0 +1,235 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"io"
+	"mime"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gopkg.in/macaron.v1"
+)
+
+type (
+	// Decoder deserializes a request body into the value pointed to by v.
+	// A Decoder may return an Error to report a problem with a specific
+	// classification; any other error is reported as ERR_DESERIALIZATION.
+	Decoder interface {
+		Decode(r io.Reader, v interface{}) error
+	}
+
+	// DecoderFunc is an adapter to allow the use of ordinary functions
+	// as a Decoder.
+	DecoderFunc func(r io.Reader, v interface{}) error
+
+	// configurableDecoder is implemented by decoders that behave as the
+	// Binder and the model they decode into ask for, e.g. strictly.
+	configurableDecoder interface {
+		Decoder
+		configure(b *Binder, obj interface{}) Decoder
+	}
+
+	// binderFunc is the common signature of the binding middleware
+	// that Bind dispatches to.
+	binderFunc func(obj interface{}, ifacePtr ...interface{}) macaron.Handler
+)
+
+// Decode calls f(r, v).
+func (f DecoderFunc) Decode(r io.Reader, v interface{}) error {
+	return f(r, v)
+}
+
+// contentBinder is what is registered for a media type: the middleware
+// that Bind dispatches to and, for the formats that have one, the decoder
+// that Request uses to fill the body fields of its model.
+type contentBinder struct {
+	binder  binderFunc
+	decoder Decoder
+}
+
+var (
+	jsonBinder     = contentBinder{Json, jsonDecoder{}}
+	yamlBinder     = contentBinder{Yaml, yamlDecoder{}}
+	tomlBinder     = contentBinder{Toml, DecoderFunc(decodeToml)}
+	xmlBinder      = contentBinder{Xml, DecoderFunc(decodeXml)}
+	msgpackBinder  = contentBinder{Msgpack, DecoderFunc(decodeMsgpack)}
+	cborBinder     = contentBinder{Cbor, DecoderFunc(decodeCbor)}
+	protobufBinder = contentBinder{Protobuf, DecoderFunc(decodeProtobuf)}
+
+	bindersLock sync.RWMutex
+	// binders maps a media type or a structured syntax suffix (e.g. "+json")
+	// to what is used to bind requests of that Content-Type.
+	binders = map[string]contentBinder{
+		"application/x-www-form-urlencoded": {binder: Form},
+		"multipart/form-data":               {binder: MultipartForm},
+
+		"application/json": jsonBinder,
+		"text/json":        jsonBinder,
+		"+json":            jsonBinder,
+
+		"application/yaml":   yamlBinder,
+		"application/x-yaml": yamlBinder,
+		"text/yaml":          yamlBinder,
+		"text/x-yaml":        yamlBinder,
+		"+yaml":              yamlBinder,
+
+		"application/toml": tomlBinder,
+		"+toml":            tomlBinder,
+
+		"application/xml": xmlBinder,
+		"text/xml":        xmlBinder,
+		"+xml":            xmlBinder,
+
+		"application/msgpack":     msgpackBinder,
+		"application/x-msgpack":   msgpackBinder,
+		"application/vnd.msgpack": msgpackBinder,
+		"application/cbor":        cborBinder,
+		"+cbor":                   cborBinder,
+
+		"text/csv": {binder: csvBinder},
+
+		"application/x-protobuf":          protobufBinder,
+		"application/protobuf":            protobufBinder,
+		"application/vnd.google.protobuf": protobufBinder,
+	}
+)
+
+// RegisterDecoder makes Bind use the given decoder for requests of the
+// given media type, replacing any decoder (built-in ones included) that
+// was previously registered for it. The media type may also be a
+// structured syntax suffix such as "+json" or "+xml".
+//
+// Bind picks a decoder for a Content-Type in the following order:
+// the exact media type (parameters like charset are ignored), then its
+// structured syntax suffix. Content-Types matching neither are rejected
+// with ERR_CONTENT_TYPE.
+func RegisterDecoder(mediaType string, d Decoder) {
+	registerBinder(mediaType, contentBinder{
+		binder: func(obj interface{}, ifacePtr ...interface{}) macaron.Handler {
+			return Decode(d, obj, ifacePtr...)
+		},
+		decoder: d,
+	})
+}
+
+func registerBinder(mediaType string, binder contentBinder) {
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if !strings.HasPrefix(mediaType, "+") {
+		mt, _, err := mime.ParseMediaType(mediaType)
+		if err != nil {
+			panic("binding: invalid media type " + mediaType + ": " + err.Error())
+		}
+		mediaType = mt
+	} else if len(mediaType) == 1 {
+		panic("binding: empty structured syntax suffix")
+	}
+
+	bindersLock.Lock()
+	defer bindersLock.Unlock()
+	binders[mediaType] = binder
+}
+
+// lookupBinder returns the middleware registered for the given
+// Content-Type, or nil if there is none.
+func lookupBinder(contentType string) binderFunc {
+	return lookupContentBinder(contentType).binder
+}
+
+// lookupDecoder returns the decoder registered for the given
+// Content-Type, or nil if there is none.
+func lookupDecoder(contentType string) Decoder {
+	return lookupContentBinder(contentType).decoder
+}
+
+func lookupContentBinder(contentType string) contentBinder {
+	// A malformed parameter does not make the media type unusable.
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil && err != mime.ErrInvalidMediaParameter {
+		return contentBinder{}
+	}
+
+	bindersLock.RLock()
+	defer bindersLock.RUnlock()
+	if binder, ok := binders[mediaType]; ok {
+		return binder
+	}
+	if i := strings.LastIndexByte(mediaType, '+'); i > 0 {
+		return binders[mediaType[i:]]
+	}
+	return contentBinder{}
+}
+
+// Decode is middleware to deserialize the request body with the given
+// decoder into the struct that is passed in. The resulting struct is then
+// validated, but no error handling is actually performed here. If the body
+// cannot be read or decoded, an empty struct is mapped unvalidated.
+// An interface pointer can be added as a second argument in order
+// to map the struct to a specific interface.
+func Decode(d Decoder, obj interface{}, ifacePtr ...interface{}) macaron.Handler {
+	return func(ctx *macaron.Context) {
+		var errors Errors
+		ensureNotPointer(obj)
+		obj := reflect.New(reflect.TypeOf(obj))
+		errors = decodeBody(ctx, d, obj, errors)
+		if errors != nil {
+			mapUnvalidated(reflect.New(obj.Elem().Type()), ctx, errors, ifacePtr...)
+			return
+		}
+		validateAndMap(obj, ctx, errors, ifacePtr...)
+	}
+}
+
+// decodeErrors carries several errors out of the built-in decoders
+// at once, for decodeBody to report each of them.
+type decodeErrors Errors
+
+func (e decodeErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// decodeBody runs the decoder over the request body, if any,
+// and collects the errors it reports.
+func decodeBody(ctx *macaron.Context, d Decoder, obj reflect.Value, errors Errors) Errors {
+	if ctx.Req.Request.Body == nil {
+		return errors
+	}
+	defer ctx.Req.Request.Body.Close()
+
+	if err := decompressBody(ctx); err != nil {
+		return addReadError(ctx, errors, err)
+	}
+	if cd, ok := d.(configurableDecoder); ok {
+		d = cd.configure(binderOf(ctx), obj.Interface())
+	}
+	err := d.Decode(ctx.Req.Request.Body, obj.Interface())
+	switch e := err.(type) {
+	case nil:
+	case Error:
+		errors = append(errors, e)
+	case decodeErrors:
+		errors = append(errors, e...)
+	default:
+		if err != io.EOF {
+			errors = addReadError(ctx, errors, err)
+		}
+	}
+	return errors
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/macaron.v1"
+)
+
+// titleDecoder takes the whole body as the title of a Post.
+var titleDecoder = DecoderFunc(func(r io.Reader, v interface{}) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if string(data) == "bad" {
+		return errors.New("bad title")
+	}
+	if string(data) == "forbidden" {
+		return Error{Classification: "ForbiddenError", Message: "Forbidden title"}
+	}
+	v.(*Post).Title = string(data)
+	return nil
+})
+
+func init() {
+	RegisterDecoder("text/x-title; charset=utf-8", titleDecoder)
+	RegisterDecoder("application/vnd.title+json", titleDecoder)
+}
+
+func Test_RegisterDecoder(t *testing.T) {
+	Convey("Register custom decoders", t, func() {
+		Convey("Invalid media types", func() {
+			So(func() { RegisterDecoder("", titleDecoder) }, ShouldPanic)
+			So(func() { RegisterDecoder("+", titleDecoder) }, ShouldPanic)
+		})
+
+		Convey("Lookup precedence", func() {
+			So(lookupBinder(""), ShouldBeNil)
+			So(lookupBinder("BoGuS"), ShouldBeNil)
+			So(lookupBinder("text/x-title"), ShouldNotBeNil)
+			So(lookupBinder("application/vnd.blog+json; charset=utf-8"), ShouldNotBeNil)
+			So(lookupBinder("application/json; charset"), ShouldNotBeNil)
+			So(lookupBinder("application/vnd.blog+yaml"), ShouldNotBeNil)
+			So(lookupBinder("application/vnd.blog+bogus"), ShouldBeNil)
+		})
+
+		performDecoderTest := func(contentType, payload string, expected Post, expectedErr string) {
+			m := macaron.Classic()
+			m.Post(testRoute, BindIgnErr(Post{}), func(actual Post, errs Errors) {
+				So(actual, ShouldResemble, expected)
+				if expectedErr == "" {
+					So(errs, ShouldHaveLength, 0)
+				} else {
+					So(errs.Has(expectedErr), ShouldBeTrue)
+				}
+			})
+
+			req, err := http.NewRequest("POST", testRoute, strings.NewReader(payload))
+			So(err, ShouldBeNil)
+			req.Header.Set("Content-Type", contentType)
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+		}
+
+		Convey("Custom media type", func() {
+			performDecoderTest("text/x-title", "Glorious Post Title", Post{Title: "Glorious Post Title"}, "")
+		})
+
+		Convey("Exact media type takes precedence over suffix", func() {
+			performDecoderTest("application/vnd.title+json", "Glorious Post Title", Post{Title: "Glorious Post Title"}, "")
+		})
+
+		Convey("Structured syntax suffix", func() {
+			performDecoderTest("application/vnd.blog+json", `{"title":"Glorious Post Title"}`, Post{Title: "Glorious Post Title"}, "")
+			performDecoderTest("application/vnd.blog+yaml", `title: Glorious Post Title`, Post{Title: "Glorious Post Title"}, "")
+		})
+
+		Convey("Decoder errors", func() {
+			performDecoderTest("text/x-title", "bad", Post{}, ERR_DESERIALIZATION)
+			performDecoderTest("text/x-title", "forbidden", Post{}, "ForbiddenError")
+		})
+
+		Convey("Validation runs on decoded models", func() {
+			performDecoderTest("text/x-title", "Short", Post{Title: "Short"}, "LengthError")
+		})
+	})
+}
@@ -0,0 +1,154 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"gopkg.in/macaron.v1"
+)
+
+type (
+	// Decompressor returns a reader of the content of r decoded from the
+	// Content-Encoding it is registered for.
+	Decompressor func(r io.Reader) (io.ReadCloser, error)
+
+	// decompressedBody is a request body read through a decompressor,
+	// closing both once it is closed.
+	decompressedBody struct {
+		io.ReadCloser
+		body io.Closer
+	}
+
+	// encodingError is reported for a Content-Encoding without a
+	// Decompressor.
+	encodingError struct {
+		encoding string
+	}
+)
+
+func (e *encodingError) Error() string {
+	return "Unsupported Content-Encoding " + e.encoding
+}
+
+func (b decompressedBody) Close() error {
+	err := b.ReadCloser.Close()
+	if bodyErr := b.body.Close(); err == nil {
+		err = bodyErr
+	}
+	return err
+}
+
+// Maximum size a compressed request body may decompress to, unless the
+// Binder sets another one with SetMaxDecompressedBytes.
+// Set this to whatever value you prefer; default is 10 MB.
+var MaxDecompressedBytes = int64(1024 * 1024 * 10)
+
+var (
+	decompressorsLock sync.RWMutex
+	decompressors     = map[string]Decompressor{
+		"gzip":    decompressGzip,
+		"x-gzip":  decompressGzip,
+		"deflate": decompressDeflate,
+	}
+)
+
+// RegisterEncoding makes the binding middleware decode request bodies of
+// the given Content-Encoding with d, replacing any Decompressor (built-in
+// ones included) that was previously registered for it. Only gzip and
+// deflate are built in; encodings like zstd or br can be added with the
+// package of your choice, e.g.
+//
+//	binding.RegisterEncoding("br", func(r io.Reader) (io.ReadCloser, error) {
+//		return io.NopCloser(brotli.NewReader(r)), nil
+//	})
+func RegisterEncoding(encoding string, d Decompressor) {
+	decompressorsLock.Lock()
+	defer decompressorsLock.Unlock()
+	decompressors[strings.ToLower(strings.TrimSpace(encoding))] = d
+}
+
+func lookupEncoding(encoding string) Decompressor {
+	decompressorsLock.RLock()
+	defer decompressorsLock.RUnlock()
+	return decompressors[encoding]
+}
+
+func decompressGzip(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// decompressDeflate reads the zlib format that deflate stands for, and
+// the raw deflate format some clients send instead.
+func decompressDeflate(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+	if header[0]&0x0f == 8 && (uint(header[0])<<8|uint(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// decompressBody replaces the request body by its content decoded from
+// its Content-Encoding, if any, and limits it to the decompressed size the
+// Binder allows. The Content-Encoding header is removed, so that binding
+// middleware coming after it read the decoded body as is.
+// An encoding without a Decompressor is reported as an encodingError.
+func decompressBody(ctx *macaron.Context) error {
+	// Encodings may be listed on several header lines as well.
+	header := strings.Join(ctx.Req.Header.Values("Content-Encoding"), ",")
+	if len(header) == 0 || ctx.Req.Request.Body == nil {
+		return nil
+	}
+
+	// Encodings are listed in the order they were applied in.
+	encodings := strings.Split(header, ",")
+	body := ctx.Req.Request.Body
+	for i := len(encodings) - 1; i >= 0; i-- {
+		encoding := strings.ToLower(strings.TrimSpace(encodings[i]))
+		if len(encoding) == 0 || encoding == "identity" {
+			continue
+		}
+		d := lookupEncoding(encoding)
+		if d == nil {
+			return &encodingError{encoding: encoding}
+		}
+		r, err := d(body)
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return fmt.Errorf("Invalid %s content: %w", encoding, err)
+		}
+		body = decompressedBody{ReadCloser: r, body: body}
+	}
+
+	ctx.Req.Header.Del("Content-Encoding")
+	ctx.Req.Header.Del("Content-Length")
+	ctx.Req.ContentLength = -1
+	ctx.Req.Request.Body = body
+	limitBody(ctx, binderOf(ctx).getMaxDecompressedBytes())
+	return nil
+}
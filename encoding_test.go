@@ -0,0 +1,133 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/macaron.v1"
+)
+
+func compress(encoding, payload string) string {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	}
+	_, _ = io.WriteString(w, payload)
+	_ = w.Close()
+	return buf.String()
+}
+
+func Test_ContentEncoding(t *testing.T) {
+	Convey("Test compressed request bodies", t, func() {
+		performEncodingTest := func(handlers []macaron.Handler, contentType, encoding, payload string, expectedCode int, expectedClass string) {
+			m := macaron.Classic()
+			handlers = append(handlers, Bind(Post{}), func(post Post) {
+				So(post.Title, ShouldEqual, "Glorious Post Title")
+				So(post.Content, ShouldEqual, "Lorem ipsum dolor sit amet")
+			})
+			m.Post(testRoute, handlers...)
+
+			req, err := http.NewRequest("POST", testRoute, strings.NewReader(payload))
+			So(err, ShouldBeNil)
+			req.Header.Set("Content-Type", contentType)
+			req.Header.Set("Content-Encoding", encoding)
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, expectedCode)
+			if len(expectedClass) > 0 {
+				So(resp.Body.String(), ShouldContainSubstring, `"classification":"`+expectedClass+`"`)
+				So(resp.Body.String(), ShouldNotContainSubstring, ERR_REQUIRED)
+			}
+		}
+
+		jsonPayload := `{"title": "Glorious Post Title", "content": "Lorem ipsum dolor sit amet"}`
+		formPayload := "title=Glorious+Post+Title&content=Lorem+ipsum+dolor+sit+amet"
+
+		Convey("Supported encodings", func() {
+			performEncodingTest(nil, _JSON_CONTENT_TYPE, "gzip", compress("gzip", jsonPayload), http.StatusOK, "")
+			performEncodingTest(nil, _YAML_CONTENT_TYPE, "x-gzip",
+				compress("gzip", "title: Glorious Post Title\ncontent: Lorem ipsum dolor sit amet"), http.StatusOK, "")
+			performEncodingTest(nil, formContentType, "deflate", compress("deflate", formPayload), http.StatusOK, "")
+			performEncodingTest(nil, formContentType, "deflate", compress("raw", formPayload), http.StatusOK, "")
+			performEncodingTest(nil, _JSON_CONTENT_TYPE, "deflate, gzip",
+				compress("gzip", compress("deflate", jsonPayload)), http.StatusOK, "")
+			performEncodingTest(nil, _JSON_CONTENT_TYPE, "identity", jsonPayload, http.StatusOK, "")
+		})
+
+		Convey("Encodings on several header lines", func() {
+			m := macaron.Classic()
+			m.Post(testRoute, Bind(Post{}), func(post Post) {
+				So(post.Title, ShouldEqual, "Glorious Post Title")
+			})
+
+			req, err := http.NewRequest("POST", testRoute, strings.NewReader(compress("gzip", compress("deflate", jsonPayload))))
+			So(err, ShouldBeNil)
+			req.Header.Set("Content-Type", _JSON_CONTENT_TYPE)
+			req.Header.Add("Content-Encoding", "deflate")
+			req.Header.Add("Content-Encoding", "gzip")
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+		})
+
+		Convey("Unsupported encodings", func() {
+			performEncodingTest(nil, _JSON_CONTENT_TYPE, "br", jsonPayload, http.StatusUnsupportedMediaType, ERR_CONTENT_ENCODING)
+			performEncodingTest(nil, formContentType, "zstd", formPayload, http.StatusUnsupportedMediaType, ERR_CONTENT_ENCODING)
+		})
+
+		Convey("Registered encodings", func() {
+			RegisterEncoding("x-test", func(r io.Reader) (io.ReadCloser, error) {
+				return io.NopCloser(r), nil
+			})
+			performEncodingTest(nil, _JSON_CONTENT_TYPE, "X-Test", jsonPayload, http.StatusOK, "")
+		})
+
+		Convey("Corrupt content", func() {
+			performEncodingTest(nil, _JSON_CONTENT_TYPE, "gzip", jsonPayload, http.StatusBadRequest, ERR_DESERIALIZATION)
+			gzipped := compress("gzip", jsonPayload)
+			performEncodingTest(nil, _JSON_CONTENT_TYPE, "gzip", gzipped[:len(gzipped)/2], http.StatusBadRequest, ERR_DESERIALIZATION)
+		})
+
+		Convey("Decompressed size limit", func() {
+			bomb := compress("gzip", `{"title": "`+strings.Repeat(" ", 1<<20)+`"}`)
+			So(len(bomb), ShouldBeLessThan, 4096)
+
+			b := NewBinder()
+			b.SetMaxDecompressedBytes(4096)
+			performEncodingTest([]macaron.Handler{b.Handler()}, _JSON_CONTENT_TYPE, "gzip", bomb, http.StatusRequestEntityTooLarge, ERR_BODY_TOO_LARGE)
+			performEncodingTest([]macaron.Handler{b.Handler()}, formContentType, "deflate",
+				compress("deflate", "title="+strings.Repeat("a", 1<<20)), http.StatusRequestEntityTooLarge, ERR_BODY_TOO_LARGE)
+
+			b.SetMaxDecompressedBytes(int64(len(jsonPayload)))
+			performEncodingTest([]macaron.Handler{b.Handler()}, _JSON_CONTENT_TYPE, "gzip", compress("gzip", jsonPayload), http.StatusOK, "")
+		})
+	})
+}
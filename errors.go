@@ -17,11 +17,26 @@ package binding
 
 const (
 	// Type mismatch errors.
-	ERR_CONTENT_TYPE    = "ContentTypeError"
-	ERR_DESERIALIZATION = "DeserializationError"
-	ERR_INTERGER_TYPE   = "IntegerTypeError"
-	ERR_BOOLEAN_TYPE    = "BooleanTypeError"
-	ERR_FLOAT_TYPE      = "FloatTypeError"
+	ERR_CONTENT_TYPE     = "ContentTypeError"
+	ERR_CONTENT_ENCODING = "ContentEncodingError"
+	ERR_BODY_TOO_LARGE   = "BodyTooLargeError"
+	ERR_DESERIALIZATION  = "DeserializationError"
+	ERR_INTERGER_TYPE    = "IntegerTypeError"
+	ERR_BOOLEAN_TYPE     = "BooleanTypeError"
+	ERR_FLOAT_TYPE       = "FloatTypeError"
+	ERR_COMPLEX_TYPE     = "ComplexTypeError"
+	ERR_OVERFLOW         = "OverflowError"
+	ERR_ARRAY_LENGTH     = "ArrayLengthError"
+	ERR_TIME_TYPE        = "TimeTypeError"
+	ERR_DURATION_TYPE    = "DurationTypeError"
+	ERR_TEXT_TYPE        = "TextTypeError"
+	ERR_CONVERSION       = "ConversionError"
+
+	// Strict decoding errors.
+	ERR_UNKNOWN_FIELD  = "UnknownFieldError"
+	ERR_DUPLICATE_KEY  = "DuplicateKeyError"
+	ERR_TRAILING_DATA  = "TrailingDataError"
+	ERR_LIMIT_EXCEEDED = "LimitExceededError"
 
 	// Validation errors.
 	ERR_REQUIRED       = "RequiredError"
@@ -66,6 +81,18 @@ type (
 		// an error in the 41st object. The message should help the
 		// end user find and fix the error with their request.
 		Message string `json:"message,omitempty"`
+
+		// Line and Column locate the problem in the request body, when
+		// the decoder is able to tell. Both are 1-based.
+		Line   int `json:"line,omitempty"`
+		Column int `json:"column,omitempty"`
+
+		// Offset is the number of bytes of the request body read up to
+		// and including the problem, when the decoder is able to tell.
+		Offset int64 `json:"offset,omitempty"`
+
+		// Expected names the type a value could not be decoded into.
+		Expected string `json:"expected,omitempty"`
 	}
 )
 
@@ -22,6 +22,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 	"gopkg.in/macaron.v1"
@@ -278,5 +279,377 @@ func Test_Default(t *testing.T) {
 		So(err, ShouldBeNil)
 
 		m.ServeHTTP(resp, req)
+
+		Convey("Defaults are converted by the binder of the request", func() {
+			b := NewBinder()
+			b.UseProfile("de")
+			m := macaron.Classic()
+			m.Get("/", b.Handler(), Form(defaultRateForm{}), func(f defaultRateForm, errs Errors) {
+				So(errs, ShouldBeEmpty)
+				So(f.Rate, ShouldEqual, 1.5)
+			})
+			resp := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", "/", nil)
+			So(err, ShouldBeNil)
+
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+		})
+	})
+}
+
+type defaultRateForm struct {
+	Rate float64 `form:"rate" binding:"Default(1,5)"`
+}
+
+type (
+	Address struct {
+		Street string `form:"street"`
+		City   string `form:"city" binding:"Required"`
+	}
+
+	LineItem struct {
+		Sku      string `form:"sku" binding:"Required"`
+		Quantity int    `form:"qty"`
+	}
+
+	orderForm struct {
+		Shipping Address    `form:"shipping"`
+		Billing  *Address   `form:"billing"`
+		Items    []LineItem `form:"items"`
+		Gifts    []*LineItem
+		Tags     []string `form:"tags"`
+	}
+)
+
+func Test_NestedForm(t *testing.T) {
+	Convey("Test nested and indexed form keys", t, func() {
+		performNestedFormTest := func(payload string, expected orderForm, expectedErrors Errors) {
+			m := macaron.Classic()
+			m.Post(testRoute, Form(orderForm{}), func(actual orderForm, errs Errors) {
+				So(actual, ShouldResemble, expected)
+				if len(expectedErrors) == 0 {
+					So(errs, ShouldBeEmpty)
+				} else {
+					So(errs, ShouldResemble, expectedErrors)
+				}
+			})
+
+			req, err := http.NewRequest("POST", testRoute, strings.NewReader(payload))
+			So(err, ShouldBeNil)
+			req.Header.Set("Content-Type", formContentType)
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+		}
+
+		Convey("Nested structs do not collide", func() {
+			performNestedFormTest("shipping.city=Berlin&shipping.street=Main+St&billing[city]=Paris",
+				orderForm{
+					Shipping: Address{Street: "Main St", City: "Berlin"},
+					Billing:  &Address{City: "Paris"},
+				}, nil)
+		})
+
+		Convey("Indexed slices of structs", func() {
+			performNestedFormTest("shipping.city=Berlin&items[1].sku=b&items[0].sku=a&items[0][qty]=2&gifts[0][sku]=g",
+				orderForm{
+					Shipping: Address{City: "Berlin"},
+					Items:    []LineItem{{Sku: "a", Quantity: 2}, {Sku: "b"}},
+					Gifts:    []*LineItem{{Sku: "g"}},
+				}, nil)
+		})
+
+		Convey("Appended slice elements", func() {
+			performNestedFormTest("shipping.city=Berlin&items[][sku]=a&items[][qty]=1&items[][sku]=b&items[][qty]=3&tags[]=x&tags[]=y",
+				orderForm{
+					Shipping: Address{City: "Berlin"},
+					Items:    []LineItem{{Sku: "a", Quantity: 1}, {Sku: "b", Quantity: 3}},
+					Tags:     []string{"x", "y"},
+				}, nil)
+		})
+
+		Convey("Indexed scalar slices", func() {
+			performNestedFormTest("shipping.city=Berlin&tags[1]=y&tags[0]=x",
+				orderForm{
+					Shipping: Address{City: "Berlin"},
+					Tags:     []string{"x", "y"},
+				}, nil)
+		})
+
+		Convey("Errors report the full path", func() {
+			performNestedFormTest("billing.street=Main+St&items[0].sku=a&items[1].qty=many&items[2].qty=1",
+				orderForm{
+					Billing: &Address{Street: "Main St"},
+					Items:   []LineItem{{Sku: "a"}, {}, {Quantity: 1}},
+				},
+				Errors{
+					{FieldNames: []string{"items[1].qty"}, Classification: ERR_INTERGER_TYPE, Message: "Value could not be parsed as integer"},
+					{FieldNames: []string{"shipping.city"}, Classification: ERR_REQUIRED, Message: "Required"},
+					{FieldNames: []string{"billing.city"}, Classification: ERR_REQUIRED, Message: "Required"},
+					{FieldNames: []string{"items[1].sku"}, Classification: ERR_REQUIRED, Message: "Required"},
+					{FieldNames: []string{"items[2].sku"}, Classification: ERR_REQUIRED, Message: "Required"},
+				})
+		})
+
+		Convey("Errors keep the index sent", func() {
+			performNestedFormTest("shipping.city=Berlin&items[5].sku=a&items[9].qty=many&items[][qty]=1",
+				orderForm{
+					Shipping: Address{City: "Berlin"},
+					Items:    []LineItem{{Sku: "a"}, {}, {Quantity: 1}},
+				},
+				Errors{
+					{FieldNames: []string{"items[9].qty"}, Classification: ERR_INTERGER_TYPE, Message: "Value could not be parsed as integer"},
+					{FieldNames: []string{"items[9].sku"}, Classification: ERR_REQUIRED, Message: "Required"},
+					{FieldNames: []string{"items[10].sku"}, Classification: ERR_REQUIRED, Message: "Required"},
+				})
+		})
+	})
+}
+
+type listingForm struct {
+	Meta   map[string]string   `form:"meta"`
+	Counts map[string]int      `form:"count"`
+	Tags   map[string][]string `form:"tag"`
+	Ranks  map[int]string      `form:"rank"`
+	Places map[string]Address  `form:"place"`
+}
+
+func Test_MapForm(t *testing.T) {
+	Convey("Test keyed form keys", t, func() {
+		performMapFormTest := func(query string, expected listingForm, expectedErrors Errors) {
+			m := macaron.Classic()
+			m.Get(testRoute, Form(listingForm{}), func(actual listingForm, errs Errors) {
+				So(actual, ShouldResemble, expected)
+				if len(expectedErrors) == 0 {
+					So(errs, ShouldBeEmpty)
+				} else {
+					So(errs, ShouldResemble, expectedErrors)
+				}
+			})
+
+			req, err := http.NewRequest("GET", testRoute+query, nil)
+			So(err, ShouldBeNil)
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+		}
+
+		Convey("Maps of every kind", func() {
+			performMapFormTest("?meta[color]=red&meta.size=L&count[a]=1&count[b]=2&tag[x]=1&tag[x]=2&rank[1]=gold&place[home][city]=Berlin",
+				listingForm{
+					Meta:   map[string]string{"color": "red", "size": "L"},
+					Counts: map[string]int{"a": 1, "b": 2},
+					Tags:   map[string][]string{"x": {"1", "2"}},
+					Ranks:  map[int]string{1: "gold"},
+					Places: map[string]Address{"home": {City: "Berlin"}},
+				}, nil)
+		})
+
+		Convey("Errors carry the map key", func() {
+			performMapFormTest("?count[a]=1&count[b]=two&rank[first]=gold",
+				listingForm{
+					Counts: map[string]int{"a": 1, "b": 0},
+					Ranks:  map[int]string{},
+				},
+				Errors{
+					{FieldNames: []string{"count[b]"}, Classification: ERR_INTERGER_TYPE, Message: "Value could not be parsed as integer"},
+					{FieldNames: []string{"rank[first]"}, Classification: ERR_INTERGER_TYPE, Message: "Value could not be parsed as integer"},
+				})
+		})
+	})
+}
+
+type profilePatch struct {
+	Name   *string    `form:"name" binding:"OmitEmpty;MinSize(2)"`
+	Age    *int       `form:"age" binding:"Range(0,150)"`
+	Admin  *bool      `form:"admin"`
+	Email  *string    `form:"email" binding:"Required"`
+	Since  *time.Time `form:"since" time_format:"2006-01-02"`
+	Scores []*int     `form:"score"`
+}
+
+func Test_PointerForm(t *testing.T) {
+	Convey("Test pointer fields in form binding", t, func() {
+		performPointerFormTest := func(payload string, check func(actual profilePatch, errs Errors)) {
+			m := macaron.Classic()
+			m.Patch(testRoute, Form(profilePatch{}), check)
+
+			req, err := http.NewRequest("PATCH", testRoute, strings.NewReader(payload))
+			So(err, ShouldBeNil)
+			req.Header.Set("Content-Type", formContentType)
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+		}
+
+		Convey("Absent values stay nil", func() {
+			performPointerFormTest("", func(actual profilePatch, errs Errors) {
+				So(actual, ShouldResemble, profilePatch{})
+				So(errs, ShouldResemble, Errors{
+					{FieldNames: []string{"Email"}, Classification: ERR_REQUIRED, Message: "Required"},
+				})
+			})
+		})
+
+		Convey("Zero values are present", func() {
+			performPointerFormTest("name=&age=0&admin=false&email=&since=2021-03-04&score=0&score=7", func(actual profilePatch, errs Errors) {
+				So(*actual.Name, ShouldEqual, "")
+				So(*actual.Age, ShouldEqual, 0)
+				So(*actual.Admin, ShouldBeFalse)
+				So(*actual.Email, ShouldEqual, "")
+				So(actual.Since.Format("2006-01-02"), ShouldEqual, "2021-03-04")
+				So(actual.Scores, ShouldHaveLength, 2)
+				So(*actual.Scores[0], ShouldEqual, 0)
+				So(*actual.Scores[1], ShouldEqual, 7)
+				So(errs, ShouldResemble, Errors{
+					{FieldNames: []string{"Name"}, Classification: ERR_MIN_SIZE, Message: "MinSize"},
+				})
+			})
+		})
+
+		Convey("Rules check the value pointed to", func() {
+			performPointerFormTest("name=Jo&age=200&email=jo@example.com", func(actual profilePatch, errs Errors) {
+				So(*actual.Name, ShouldEqual, "Jo")
+				So(*actual.Age, ShouldEqual, 200)
+				So(errs, ShouldResemble, Errors{
+					{FieldNames: []string{"Age"}, Classification: ERR_RANGE, Message: "Range"},
+				})
+			})
+		})
+
+		Convey("Values that cannot be converted stay nil", func() {
+			performPointerFormTest("age=old&email=jo@example.com", func(actual profilePatch, errs Errors) {
+				So(actual.Age, ShouldBeNil)
+				So(errs, ShouldResemble, Errors{
+					{FieldNames: []string{"age"}, Classification: ERR_INTERGER_TYPE, Message: "Value could not be parsed as integer"},
+				})
+			})
+		})
+	})
+}
+
+type searchForm struct {
+	Ids    []int     `form:"ids" collection_format:"csv"`
+	Words  []string  `form:"q" collection_format:"ssv"`
+	Fields []string  `form:"fields" collection_format:"tsv"`
+	Tags   []string  `form:"tags" collection_format:"pipes"`
+	Scores []float64 `form:"score" collection_format:"multi"`
+}
+
+func Test_CollectionFormat(t *testing.T) {
+	Convey("Test delimited slice values", t, func() {
+		performCollectionTest := func(query string, expected searchForm, expectedErrors Errors) {
+			m := macaron.Classic()
+			m.Get(testRoute, Form(searchForm{}), func(actual searchForm, errs Errors) {
+				So(actual, ShouldResemble, expected)
+				if len(expectedErrors) == 0 {
+					So(errs, ShouldBeEmpty)
+				} else {
+					So(errs, ShouldResemble, expectedErrors)
+				}
+			})
+
+			req, err := http.NewRequest("GET", testRoute+query, nil)
+			So(err, ShouldBeNil)
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+		}
+
+		Convey("Every collection format", func() {
+			performCollectionTest("?ids=1,2,3&ids=4&q=foo+bar&fields=a%09b&tags=x|y&score=1.5&score=2",
+				searchForm{
+					Ids:    []int{1, 2, 3, 4},
+					Words:  []string{"foo", "bar"},
+					Fields: []string{"a", "b"},
+					Tags:   []string{"x", "y"},
+					Scores: []float64{1.5, 2},
+				}, nil)
+		})
+
+		Convey("Empty values have no elements", func() {
+			performCollectionTest("?ids=&tags=", searchForm{}, nil)
+		})
+
+		Convey("Errors carry the element index", func() {
+			performCollectionTest("?ids=1,two,3&score=1&score=high",
+				searchForm{
+					Ids:    []int{1, 0, 3},
+					Scores: []float64{1, 0},
+				},
+				Errors{
+					{FieldNames: []string{"ids[1]"}, Classification: ERR_INTERGER_TYPE, Message: "Value could not be parsed as integer"},
+					{FieldNames: []string{"score[1]"}, Classification: ERR_FLOAT_TYPE, Message: "Value could not be parsed as 64-bit float"},
+				})
+		})
+
+		Convey("Unknown formats fail when the middleware is built", func() {
+			So(func() {
+				Form(struct {
+					Ids []int `form:"ids" collection_format:"cvs"`
+				}{})
+			}, ShouldPanic)
+		})
+	})
+}
+
+type routeForm struct {
+	Origin [2]float64  `form:"origin"`
+	Legs   [2]LineItem `form:"legs"`
+	Stops  [3]int      `form:"stop" collection_format:"csv"`
+}
+
+func Test_ArrayForm(t *testing.T) {
+	Convey("Test fixed-size arrays", t, func() {
+		performArrayFormTest := func(query string, expected routeForm, expectedErrors Errors) {
+			m := macaron.Classic()
+			m.Get(testRoute, Form(routeForm{}), func(actual routeForm, errs Errors) {
+				So(actual, ShouldResemble, expected)
+				if len(expectedErrors) == 0 {
+					So(errs, ShouldBeEmpty)
+				} else {
+					So(errs, ShouldResemble, expectedErrors)
+				}
+			})
+
+			req, err := http.NewRequest("GET", testRoute+query, nil)
+			So(err, ShouldBeNil)
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+		}
+
+		Convey("Arrays of values and structs", func() {
+			performArrayFormTest("?origin=52.5&origin=13.4&legs[0].sku=a&legs[1].sku=b&legs[1].qty=2&stop=1,2,3",
+				routeForm{
+					Origin: [2]float64{52.5, 13.4},
+					Legs:   [2]LineItem{{Sku: "a"}, {Sku: "b", Quantity: 2}},
+					Stops:  [3]int{1, 2, 3},
+				}, nil)
+		})
+
+		Convey("Length mismatch", func() {
+			performArrayFormTest("?origin=52.5&legs[0].sku=a&legs[1].sku=b&stop=1,2,3,4",
+				routeForm{
+					Legs: [2]LineItem{{Sku: "a"}, {Sku: "b"}},
+				},
+				Errors{
+					{FieldNames: []string{"origin"}, Classification: ERR_ARRAY_LENGTH, Message: "Expected 2 values, got 1"},
+					{FieldNames: []string{"stop"}, Classification: ERR_ARRAY_LENGTH, Message: "Expected 3 values, got 4"},
+				})
+		})
+
+		Convey("Structs in arrays are validated", func() {
+			performArrayFormTest("?origin=0&origin=0&legs[0].sku=a&legs[1].qty=1&legs[2].sku=c&stop=1,2,3",
+				routeForm{
+					Stops: [3]int{1, 2, 3},
+				},
+				Errors{
+					{FieldNames: []string{"legs"}, Classification: ERR_ARRAY_LENGTH, Message: "Expected 2 values, got 3"},
+					{FieldNames: []string{"legs[0].sku"}, Classification: ERR_REQUIRED, Message: "Required"},
+					{FieldNames: []string{"legs[1].sku"}, Classification: ERR_REQUIRED, Message: "Required"},
+				})
+		})
 	})
 }
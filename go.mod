@@ -1,10 +1,25 @@
 module github.com/go-macaron/binding
 
-go 1.12
+go 1.18
 
 require (
+	github.com/fxamacker/cbor/v2 v2.5.0
+	github.com/pelletier/go-toml/v2 v2.1.1
 	github.com/smartystreets/goconvey v0.0.0-20190731233626-505e41936337
 	github.com/unknwon/com v0.0.0-20190804042917-757f69c95f3e
+	github.com/vmihailenco/msgpack/v5 v5.3.5
+	google.golang.org/protobuf v1.33.0
 	gopkg.in/macaron.v1 v1.3.5
-	gopkg.in/yaml.v3 v3.0.0-20210105161348-2e78108cf5f8
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/go-macaron/inject v0.0.0-20160627170012-d8a0b8677191 // indirect
+	github.com/gopherjs/gopherjs v0.0.0-20190430165422-3e4dfb77656c // indirect
+	github.com/jtolds/gls v4.20.0+incompatible // indirect
+	github.com/smartystreets/assertions v1.0.1 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+	golang.org/x/crypto v0.0.0-20190701094942-4def268fd1a4 // indirect
+	gopkg.in/ini.v1 v1.46.0 // indirect
 )
@@ -0,0 +1,125 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/macaron.v1"
+)
+
+type (
+	ConditionalHeaders struct {
+		IfMatch []string `header:"If-Match"`
+		MaxAge  int      `header:"X-Max-Age" binding:"Range(0,3600)"`
+	}
+
+	requestHeaders struct {
+		ConditionalHeaders
+		RequestId string   `header:"x-request-id" binding:"Required;AlphaDash"`
+		Languages []string `header:"Accept-Language"`
+		DryRun    bool     `header:"X-Dry-Run"`
+		Ignored   string   `header:"-"`
+		Untagged  string
+	}
+)
+
+var headerTestCases = []headerTestCase{
+	{
+		description: "Happy path",
+		header: http.Header{
+			"X-Request-Id":    {"abc-123"},
+			"Accept-Language": {"en-US, de;q=0.8", "fr"},
+			"If-Match":        {`"v1"`, `"v2"`},
+			"X-Max-Age":       {"60"},
+			"X-Dry-Run":       {"true"},
+			"Ignored":         {"foo"},
+			"Untagged":        {"bar"},
+		},
+		expected: requestHeaders{
+			ConditionalHeaders: ConditionalHeaders{IfMatch: []string{`"v1"`, `"v2"`}, MaxAge: 60},
+			RequestId:          "abc-123",
+			Languages:          []string{"en-US", "de;q=0.8", "fr"},
+			DryRun:             true,
+		},
+	},
+	{
+		description: "Missing required header",
+		header:      http.Header{},
+		expected:    requestHeaders{},
+		expectedErrors: Errors{
+			{FieldNames: []string{"x-request-id"}, Classification: ERR_REQUIRED, Message: "Required"},
+		},
+	},
+	{
+		description: "Conversion and validation errors",
+		header: http.Header{
+			"X-Request-Id": {"abc 123"},
+			"X-Max-Age":    {"7200"},
+			"X-Dry-Run":    {"maybe"},
+		},
+		expected: requestHeaders{
+			ConditionalHeaders: ConditionalHeaders{MaxAge: 7200},
+			RequestId:          "abc 123",
+		},
+		expectedErrors: Errors{
+			{FieldNames: []string{"X-Dry-Run"}, Classification: ERR_BOOLEAN_TYPE, Message: "Value could not be parsed as boolean"},
+			{FieldNames: []string{"X-Max-Age"}, Classification: ERR_RANGE, Message: "Range"},
+			{FieldNames: []string{"x-request-id"}, Classification: ERR_ALPHA_DASH, Message: "AlphaDash"},
+		},
+	},
+}
+
+func Test_Header(t *testing.T) {
+	Convey("Test header binding", t, func() {
+		for _, testCase := range headerTestCases {
+			Convey(testCase.description, func() {
+				performHeaderTest(testCase)
+			})
+		}
+	})
+}
+
+func performHeaderTest(testCase headerTestCase) {
+	m := macaron.Classic()
+	m.Get(testRoute, Header(requestHeaders{}), func(actual requestHeaders, errs Errors) {
+		So(actual, ShouldResemble, testCase.expected)
+		if len(testCase.expectedErrors) == 0 {
+			So(errs, ShouldBeEmpty)
+		} else {
+			So(errs, ShouldResemble, testCase.expectedErrors)
+		}
+	})
+
+	req, err := http.NewRequest("GET", testRoute, nil)
+	So(err, ShouldBeNil)
+	req.Header = testCase.header
+
+	resp := httptest.NewRecorder()
+	m.ServeHTTP(resp, req)
+	So(resp.Code, ShouldEqual, http.StatusOK)
+}
+
+type (
+	headerTestCase struct {
+		description    string
+		header         http.Header
+		expected       requestHeaders
+		expectedErrors Errors
+	}
+)
@@ -0,0 +1,224 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Optional is a field that tells a value apart from an explicit null and
+// from absence, e.g. for PATCH-like updates. Set reports whether the field
+// was present in the request, Null whether it was null; Value holds the
+// value when Set is true and Null is false.
+//
+// Json and Yaml set it from the payload as is. Form sets it from a present
+// key, an empty value meaning null. Validation rules other than Required
+// and OmitEmpty only check Value, and only when there is one; Required
+// asks for a value and OmitEmpty skips the other rules when there is none.
+type Optional[T any] struct {
+	Value T
+	Set   bool
+	Null  bool
+}
+
+type (
+	// optional is implemented by Optional for any type.
+	optional interface {
+		present() bool
+		value() interface{}
+	}
+
+	// settableOptional is implemented by pointers to Optional.
+	settableOptional interface {
+		optional
+		setNull()
+		setPresent()
+		valuePtr() interface{}
+	}
+)
+
+var optionalType = reflect.TypeOf((*optional)(nil)).Elem()
+
+// Some returns an Optional holding the value.
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{Value: v, Set: true}
+}
+
+// Null returns an Optional that was set to null.
+func Null[T any]() Optional[T] {
+	return Optional[T]{Set: true, Null: true}
+}
+
+// Get returns the value and whether there is one.
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.present()
+}
+
+func (o Optional[T]) present() bool {
+	return o.Set && !o.Null
+}
+
+func (o Optional[T]) value() interface{} {
+	return o.Value
+}
+
+func (o *Optional[T]) setNull() {
+	var zero T
+	o.Value, o.Set, o.Null = zero, true, true
+}
+
+func (o *Optional[T]) setPresent() {
+	o.Set, o.Null = true, false
+}
+
+func (o *Optional[T]) valuePtr() interface{} {
+	return &o.Value
+}
+
+// MarshalJSON encodes the value, or null if there is none.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.present() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value)
+}
+
+// UnmarshalJSON decodes the value, or null.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		o.setNull()
+		return nil
+	}
+	o.setPresent()
+	return json.Unmarshal(data, &o.Value)
+}
+
+// MarshalYAML encodes the value, or null if there is none.
+func (o Optional[T]) MarshalYAML() (interface{}, error) {
+	if !o.present() {
+		return nil, nil
+	}
+	return o.Value, nil
+}
+
+// UnmarshalYAML decodes the value. Since yaml.v3 does not call it for
+// nulls, those are marked by markYamlNulls once the document is decoded.
+func (o *Optional[T]) UnmarshalYAML(node *yaml.Node) error {
+	o.setPresent()
+	return node.Decode(&o.Value)
+}
+
+// optionalOf returns the Optional held by the value, if it is one.
+func optionalOf(v reflect.Value) (optional, bool) {
+	if !v.IsValid() || !v.Type().Implements(optionalType) {
+		return nil, false
+	}
+	return v.Interface().(optional), true
+}
+
+// markYamlNulls sets the Optionals that are given an explicit null in the
+// YAML document to null.
+func markYamlNulls(n *yaml.Node, v reflect.Value) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.CanAddr() {
+		if opt, ok := v.Addr().Interface().(settableOptional); ok {
+			markYamlNulls(n, reflect.ValueOf(opt.valuePtr()))
+			return
+		}
+	}
+
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			markYamlNulls(c, v)
+		}
+	case yaml.AliasNode:
+		markYamlNulls(n.Alias, v)
+	case yaml.MappingNode:
+		if v.Kind() != reflect.Struct {
+			return
+		}
+		fields := make(map[string]reflect.Value)
+		yamlFields(v, fields)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			field, ok := fields[n.Content[i].Value]
+			if !ok {
+				continue
+			}
+			if value := n.Content[i+1]; value.ShortTag() == "!!null" {
+				if opt, ok := field.Addr().Interface().(settableOptional); ok {
+					opt.setNull()
+				}
+			} else {
+				markYamlNulls(value, field)
+			}
+		}
+	case yaml.SequenceNode:
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return
+		}
+		for i, c := range n.Content {
+			if i < v.Len() {
+				markYamlNulls(c, v.Index(i))
+			}
+		}
+	}
+}
+
+// yamlFields collects the fields of the struct by the key yaml.v3 decodes
+// them from.
+func yamlFields(v reflect.Value, fields map[string]reflect.Value) {
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		name, opts := field.Tag.Get("yaml"), ""
+		if i := strings.IndexByte(name, ','); i >= 0 {
+			name, opts = name[:i], name[i:]
+		}
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(opts, ",inline") {
+			inline := v.Field(i)
+			if inline.Kind() == reflect.Ptr {
+				if inline.IsNil() {
+					continue
+				}
+				inline = inline.Elem()
+			}
+			if inline.Kind() == reflect.Struct {
+				yamlFields(inline, fields)
+			}
+			continue
+		}
+		if len(name) == 0 {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = v.Field(i)
+	}
+}
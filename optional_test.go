@@ -0,0 +1,111 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/macaron.v1"
+)
+
+type profileUpdate struct {
+	Name Optional[string]   `json:"name" yaml:"name" form:"name" binding:"Required;MaxSize(5)"`
+	Age  Optional[int]      `json:"age" yaml:"age" form:"age" binding:"Range(0,150)"`
+	Tags Optional[[]string] `json:"tags" yaml:"tags" form:"tag" binding:"OmitEmpty;MinSize(1)"`
+	Home Optional[Address]  `json:"home" yaml:"home" form:"home"`
+}
+
+func Test_Optional(t *testing.T) {
+	Convey("Test optional fields", t, func() {
+		performOptionalTest := func(binder handlerFunc, contentType, payload string, expected profileUpdate, expectedErrors Errors) {
+			m := macaron.Classic()
+			m.Patch(testRoute, binder(profileUpdate{}), func(actual profileUpdate, errs Errors) {
+				So(actual, ShouldResemble, expected)
+				if len(expectedErrors) == 0 {
+					So(errs, ShouldBeEmpty)
+				} else {
+					So(errs, ShouldResemble, expectedErrors)
+				}
+			})
+
+			req, err := http.NewRequest("PATCH", testRoute, strings.NewReader(payload))
+			So(err, ShouldBeNil)
+			req.Header.Set("Content-Type", contentType)
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+		}
+
+		Convey("JSON", func() {
+			performOptionalTest(Json, _JSON_CONTENT_TYPE, `{"name": "Jo", "age": null, "home": {"street": "Main St"}}`,
+				profileUpdate{
+					Name: Some("Jo"),
+					Age:  Null[int](),
+					Home: Some(Address{Street: "Main St"}),
+				},
+				Errors{
+					{FieldNames: []string{"City"}, Classification: ERR_REQUIRED, Message: "Required"},
+				})
+		})
+
+		Convey("YAML", func() {
+			performOptionalTest(Yaml, _YAML_CONTENT_TYPE, "name: Joanna\nage: ~\ntags: []\nhome: null",
+				profileUpdate{
+					Name: Some("Joanna"),
+					Age:  Null[int](),
+					Tags: Some([]string{}),
+					Home: Null[Address](),
+				},
+				Errors{
+					{FieldNames: []string{"Name"}, Classification: ERR_MAX_SIZE, Message: "MaxSize"},
+					{FieldNames: []string{"Tags"}, Classification: ERR_MIN_SIZE, Message: "MinSize"},
+				})
+		})
+
+		Convey("Form", func() {
+			performOptionalTest(Form, formContentType, "name=&age=200&tag=a&tag=b",
+				profileUpdate{
+					Name: Null[string](),
+					Age:  Some(200),
+					Tags: Some([]string{"a", "b"}),
+				},
+				Errors{
+					{FieldNames: []string{"Name"}, Classification: ERR_REQUIRED, Message: "Required"},
+					{FieldNames: []string{"Age"}, Classification: ERR_RANGE, Message: "Range"},
+				})
+		})
+
+		Convey("Absent fields", func() {
+			performOptionalTest(Json, _JSON_CONTENT_TYPE, `{"name": "Jo"}`, profileUpdate{Name: Some("Jo")}, nil)
+		})
+
+		Convey("Encoding", func() {
+			data, err := json.Marshal(profileUpdate{Name: Some("Jo"), Age: Null[int]()})
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, `{"name":"Jo","age":null,"tags":null,"home":null}`)
+
+			v, ok := Some(42).Get()
+			So(v, ShouldEqual, 42)
+			So(ok, ShouldBeTrue)
+			_, ok = Null[int]().Get()
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
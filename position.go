@@ -0,0 +1,262 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// jsonError turns the errors encoding/json reports for a payload into an
+// Error that names the field by its path, e.g. "items[1].sku", and locates
+// the problem in the payload. Other errors are returned as they are.
+func jsonError(err error, w *jsonWindow, v interface{}) error {
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		return w.locate(Error{
+			FieldNames:     []string{},
+			Classification: ERR_DESERIALIZATION,
+			Message:        e.Error(),
+		}, e.Offset)
+	case *json.UnmarshalTypeError:
+		fieldNames := []string{}
+		if len(e.Field) > 0 {
+			fieldNames = []string{jsonErrorPath(reflect.TypeOf(v), e.Field)}
+		}
+		return w.locate(Error{
+			FieldNames:     fieldNames,
+			Classification: ERR_DESERIALIZATION,
+			Message:        e.Error(),
+			Expected:       e.Type.String(),
+		}, e.Offset)
+	}
+	return err
+}
+
+// jsonWindowSize is how many of the bytes read last a jsonWindow keeps.
+const jsonWindowSize = 64 << 10
+
+// jsonWindow reads a JSON payload and keeps the bytes read last, along
+// with the number of lines before them, to locate errors without holding
+// the whole payload. Reads are capped to the size of the window, so that
+// a syntax error, found in the bytes read last, always falls within it.
+type jsonWindow struct {
+	r       io.Reader
+	buf     []byte
+	start   int64 // Offset of the first byte in buf.
+	lines   int   // Newlines before buf.
+	newline int64 // Offset of the last newline before buf, or -1.
+}
+
+func newJsonWindow(r io.Reader) *jsonWindow {
+	return &jsonWindow{r: r, newline: -1}
+}
+
+func (w *jsonWindow) Read(p []byte) (int, error) {
+	if len(p) > jsonWindowSize {
+		p = p[:jsonWindowSize]
+	}
+	n, err := w.r.Read(p)
+	if len(w.buf)+n > 2*jsonWindowSize {
+		w.drop(len(w.buf) + n - jsonWindowSize)
+	}
+	w.buf = append(w.buf, p[:n]...)
+	return n, err
+}
+
+// drop forgets the first n bytes kept.
+func (w *jsonWindow) drop(n int) {
+	dropped := w.buf[:n]
+	w.lines += bytes.Count(dropped, []byte("\n"))
+	if i := bytes.LastIndexByte(dropped, '\n'); i >= 0 {
+		w.newline = w.start + int64(i)
+	}
+	w.start += int64(n)
+	w.buf = append(w.buf[:0], w.buf[n:]...)
+}
+
+// locate sets the offset of the error, and the line and column of the
+// last byte read up to it if that is still kept.
+func (w *jsonWindow) locate(e Error, offset int64) Error {
+	e.Offset = offset
+	if end := w.start + int64(len(w.buf)); offset > end {
+		offset = end
+	}
+	last := offset - 1
+	if last < w.start {
+		return e
+	}
+	read := w.buf[:last-w.start]
+	e.Line = w.lines + bytes.Count(read, []byte("\n")) + 1
+	newline := w.newline
+	if i := bytes.LastIndexByte(read, '\n'); i >= 0 {
+		newline = w.start + int64(i)
+	}
+	e.Column = int(last - newline)
+	return e
+}
+
+// jsonErrorPath turns the dotted path of a field that encoding/json
+// reports for a value of the type into the one errors name it by, e.g.
+// "items.1.sku" into "items[1].sku".
+func jsonErrorPath(typ reflect.Type, field string) string {
+	var path string
+	for _, segment := range strings.Split(field, ".") {
+		typ = jsonTarget(typ)
+		switch {
+		case typ != nil && (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array):
+			path += "[" + segment + "]"
+			typ = typ.Elem()
+		case typ != nil && typ.Kind() == reflect.Struct:
+			fields := make(map[string]reflect.Type)
+			jsonFields(typ, fields)
+			_, typ, _ = lookupJsonField(fields, segment)
+			path = joinFormPath(path, segment)
+		case typ != nil && typ.Kind() == reflect.Map:
+			typ = typ.Elem()
+			path = joinFormPath(path, segment)
+		default:
+			typ = nil
+			path = joinFormPath(path, segment)
+		}
+	}
+	return path
+}
+
+// yamlSyntaxError turns an error yaml.v3 reports for a document it cannot
+// parse into an Error on the line the message gives, e.g. "yaml: line 3:
+// found character that cannot start any token". Other errors are
+// returned as they are.
+func yamlSyntaxError(err error) error {
+	var line int
+	if _, scanErr := fmt.Sscanf(err.Error(), "yaml: line %d:", &line); scanErr != nil {
+		return err
+	}
+	return Error{
+		FieldNames:     []string{},
+		Classification: ERR_DESERIALIZATION,
+		Message:        err.Error(),
+		Line:           line,
+	}
+}
+
+var yamlUnmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+
+// yamlError turns the yaml.TypeError reported for decoding the document
+// into v into Errors that name the field by its path in the document,
+// e.g. "items[1].sku", and locate it, by going through the document
+// alongside the type of v. Other errors are returned as they are.
+func yamlError(err error, doc *yaml.Node, v interface{}, strict bool) error {
+	if _, ok := err.(*yaml.TypeError); !ok {
+		return err
+	}
+	if errs := yamlNodeErrors(nil, doc, reflect.TypeOf(v), "", strict); len(errs) > 0 {
+		return decodeErrors(errs)
+	}
+	return err
+}
+
+// yamlNodeErrors appends the errors of decoding the node, found at the
+// path in the document, into a value of the type. The node is decoded as
+// a whole unless it is a mapping or sequence the type takes apart, in
+// which case keys that match no field are reported as ERR_UNKNOWN_FIELD
+// when decoding strictly.
+func yamlNodeErrors(errs Errors, n *yaml.Node, typ reflect.Type, path string, strict bool) Errors {
+	for n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	decodesItself := reflect.PtrTo(typ).Implements(yamlUnmarshalerType)
+
+	switch {
+	case n.Kind == yaml.DocumentNode:
+		for _, c := range n.Content {
+			errs = yamlNodeErrors(errs, c, typ, path, strict)
+		}
+		return errs
+	case decodesItself:
+		// Decoded as a whole below, however it is laid out.
+	case n.Kind == yaml.MappingNode && typ.Kind() == reflect.Struct:
+		fields := make(map[string]reflect.Value)
+		yamlFields(reflect.New(typ).Elem(), fields)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			keyPath := joinFormPath(path, key.Value)
+			if field, ok := fields[key.Value]; ok {
+				errs = yamlNodeErrors(errs, value, field.Type(), keyPath, strict)
+			} else if strict && key.ShortTag() != "!!merge" && !hasYamlInlineMap(typ) {
+				errs = append(errs, Error{
+					FieldNames:     []string{keyPath},
+					Classification: ERR_UNKNOWN_FIELD,
+					Message:        fmt.Sprintf("line %d: field %s not found in type %s", key.Line, key.Value, typ),
+					Line:           key.Line,
+					Column:         key.Column,
+				})
+			}
+		}
+		return errs
+	case n.Kind == yaml.MappingNode && typ.Kind() == reflect.Map:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			errs = yamlNodeErrors(errs, n.Content[i+1], typ.Elem(), joinFormPath(path, n.Content[i].Value), strict)
+		}
+		return errs
+	case n.Kind == yaml.SequenceNode && (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array):
+		for i, c := range n.Content {
+			errs = yamlNodeErrors(errs, c, typ.Elem(), path+"["+strconv.Itoa(i)+"]", strict)
+		}
+		return errs
+	}
+
+	e, ok := n.Decode(reflect.New(typ).Interface()).(*yaml.TypeError)
+	if !ok {
+		return errs
+	}
+	fieldNames := []string{}
+	if len(path) > 0 {
+		fieldNames = []string{path}
+	}
+	for _, msg := range e.Errors {
+		errs = append(errs, Error{
+			FieldNames:     fieldNames,
+			Classification: ERR_DESERIALIZATION,
+			Message:        msg,
+			Line:           n.Line,
+			Column:         n.Column,
+			Expected:       typ.String(),
+		})
+	}
+	return errs
+}
+
+// hasYamlInlineMap reports whether the struct type takes the keys that
+// match none of its fields into an inline map.
+func hasYamlInlineMap(typ reflect.Type) bool {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Type.Kind() == reflect.Map && strings.Contains(field.Tag.Get("yaml"), ",inline") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/macaron.v1"
+)
+
+type shipment struct {
+	Id    int        `json:"id" yaml:"id"`
+	Items []LineItem `json:"items" yaml:"items"`
+	Dest  Address    `json:"dest" yaml:"dest"`
+}
+
+func Test_DecodeErrors(t *testing.T) {
+	Convey("Test locating decode errors", t, func() {
+		performDecodeErrorTest := func(binder handlerFunc, contentType, payload string, expectedErrors Errors) {
+			m := macaron.Classic()
+			m.Post(testRoute, binder(shipment{}), func(errs Errors) {
+				var decodeErrs Errors
+				for _, err := range errs {
+					if err.Classification == ERR_DESERIALIZATION {
+						decodeErrs = append(decodeErrs, err)
+					}
+				}
+				So(decodeErrs, ShouldResemble, expectedErrors)
+			})
+
+			req, err := http.NewRequest("POST", testRoute, strings.NewReader(payload))
+			So(err, ShouldBeNil)
+			req.Header.Set("Content-Type", contentType)
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+		}
+
+		Convey("JSON type errors", func() {
+			performDecodeErrorTest(Json, _JSON_CONTENT_TYPE, "{\n  \"id\": 1,\n  \"items\": [{\"Sku\": \"a\"}, {\"Sku\": 2}]\n}", Errors{
+				{
+					FieldNames:     []string{"items[1].Sku"},
+					Classification: ERR_DESERIALIZATION,
+					Message:        "json: cannot unmarshal number into Go struct field shipment.items.1.Sku of type string",
+					Line:           3,
+					Column:         35,
+					Offset:         48,
+					Expected:       "string",
+				},
+			})
+		})
+
+		Convey("JSON syntax errors", func() {
+			performDecodeErrorTest(Json, _JSON_CONTENT_TYPE, "{\n  \"id\": 1,,\n}", Errors{
+				{
+					FieldNames:     []string{},
+					Classification: ERR_DESERIALIZATION,
+					Message:        "invalid character ',' looking for beginning of object key string",
+					Line:           2,
+					Column:         11,
+					Offset:         13,
+				},
+			})
+		})
+
+		Convey("JSON errors far into the payload", func() {
+			performDecodeErrorTest(Json, _JSON_CONTENT_TYPE, "{\"id\": 1,"+strings.Repeat("\n", 100000)+"\"items\": x}", Errors{
+				{
+					FieldNames:     []string{},
+					Classification: ERR_DESERIALIZATION,
+					Message:        "invalid character 'x' looking for beginning of value",
+					Line:           100001,
+					Column:         10,
+					Offset:         100019,
+				},
+			})
+		})
+
+		Convey("YAML type errors", func() {
+			performDecodeErrorTest(Yaml, _YAML_CONTENT_TYPE, "id: one\nitems:\n  - Sku: a\n  - quantity: a lot more than that\ndest: [Berlin]", Errors{
+				{
+					FieldNames:     []string{"id"},
+					Classification: ERR_DESERIALIZATION,
+					Message:        "line 1: cannot unmarshal !!str `one` into int",
+					Line:           1,
+					Column:         5,
+					Expected:       "int",
+				},
+				{
+					FieldNames:     []string{"items[1].quantity"},
+					Classification: ERR_DESERIALIZATION,
+					Message:        "line 4: cannot unmarshal !!str `a lot m...` into int",
+					Line:           4,
+					Column:         15,
+					Expected:       "int",
+				},
+				{
+					FieldNames:     []string{"dest"},
+					Classification: ERR_DESERIALIZATION,
+					Message:        "line 5: cannot unmarshal !!seq into binding.Address",
+					Line:           5,
+					Column:         7,
+					Expected:       "binding.Address",
+				},
+			})
+		})
+
+		Convey("YAML syntax errors", func() {
+			performDecodeErrorTest(Yaml, _YAML_CONTENT_TYPE, "id: 1\nitems:\n\t- a", Errors{
+				{
+					FieldNames:     []string{},
+					Classification: ERR_DESERIALIZATION,
+					Message:        "yaml: line 3: found character that cannot start any token",
+					Line:           3,
+				},
+			})
+		})
+	})
+}
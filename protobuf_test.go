@@ -0,0 +1,124 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gopkg.in/macaron.v1"
+)
+
+func init() {
+	RegisterRules(&wrapperspb.StringValue{}, map[string]string{
+		"Value": "Required;MaxSize(10)",
+	})
+}
+
+func Test_Protobuf(t *testing.T) {
+	Convey("Test Protocol Buffers", t, func() {
+		performProtobufTest := func(binder handlerFunc, payload []byte, expected string, expectedErr string) {
+			m := macaron.Classic()
+			m.Post(testRoute, binder(wrapperspb.StringValue{}, (*proto.Message)(nil)), func(actual *wrapperspb.StringValue, msg proto.Message, errs Errors) {
+				So(actual.GetValue(), ShouldEqual, expected)
+				So(msg, ShouldEqual, actual)
+				if expectedErr == "" {
+					So(errs, ShouldHaveLength, 0)
+				} else {
+					So(errs.Has(expectedErr), ShouldBeTrue)
+				}
+			})
+
+			req, err := http.NewRequest("POST", testRoute, bytes.NewReader(payload))
+			So(err, ShouldBeNil)
+			req.Header.Set("Content-Type", _PROTOBUF_CONTENT_TYPE)
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+		}
+
+		marshal := func(s string) []byte {
+			data, err := proto.Marshal(wrapperspb.String(s))
+			So(err, ShouldBeNil)
+			return data
+		}
+
+		Convey("Happy path", func() {
+			performProtobufTest(Protobuf, marshal("hello"), "hello", "")
+		})
+
+		Convey("Registered rules are validated", func() {
+			performProtobufTest(Protobuf, marshal(""), "", ERR_REQUIRED)
+			performProtobufTest(Protobuf, marshal("hello world!"), "hello world!", ERR_MAX_SIZE)
+		})
+
+		Convey("Only a pointer to the message is mapped", func() {
+			m := macaron.Classic()
+			m.Post(testRoute, Protobuf(wrapperspb.StringValue{}), func(ctx *macaron.Context, actual *wrapperspb.StringValue) {
+				So(actual.GetValue(), ShouldEqual, "hello")
+				So(ctx.GetVal(reflect.TypeOf((*wrapperspb.StringValue)(nil)).Elem()).IsValid(), ShouldBeFalse)
+			})
+
+			req, err := http.NewRequest("POST", testRoute, bytes.NewReader(marshal("hello")))
+			So(err, ShouldBeNil)
+			req.Header.Set("Content-Type", _PROTOBUF_CONTENT_TYPE)
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+		})
+
+		Convey("Malformed payload", func() {
+			performProtobufTest(Protobuf, []byte{0xff, 0xff}, "", ERR_DESERIALIZATION)
+		})
+
+		Convey("Model is not a message", func() {
+			m := macaron.Classic()
+			m.Post(testRoute, BindIgnErr(Post{}), func(errs Errors) {
+				So(errs.Has(ERR_CONTENT_TYPE), ShouldBeTrue)
+			})
+
+			req, err := http.NewRequest("POST", testRoute, bytes.NewReader(marshal("hello")))
+			So(err, ShouldBeNil)
+			req.Header.Set("Content-Type", _PROTOBUF_CONTENT_TYPE)
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+		})
+	})
+}
+
+type taggedAndRegistered struct {
+	Name string `binding:"Required"`
+}
+
+func Test_RegisterRules(t *testing.T) {
+	Convey("Registered rules are applied after binding tags", t, func() {
+		RegisterRules(taggedAndRegistered{}, map[string]string{"Name": "MaxSize(3)"})
+
+		errs := RawValidate(taggedAndRegistered{})
+		So(errs, ShouldHaveLength, 1)
+		So(errs[0].Classification, ShouldEqual, ERR_REQUIRED)
+
+		errs = RawValidate(taggedAndRegistered{Name: "abcd"})
+		So(errs, ShouldHaveLength, 1)
+		So(errs[0].Classification, ShouldEqual, ERR_MAX_SIZE)
+	})
+}
@@ -0,0 +1,260 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"mime"
+	"net/http"
+	"net/textproto"
+	"reflect"
+	"strings"
+
+	"gopkg.in/macaron.v1"
+)
+
+// Sources of the request data a field can be bound from with the in tag.
+const (
+	IN_PATH   = "path"
+	IN_QUERY  = "query"
+	IN_HEADER = "header"
+	IN_BODY   = "body"
+)
+
+// Request is middleware to bind the whole request into the struct that
+// is passed in: route parameters, query string, headers and body alike.
+// The in tag of a field picks its source, e.g. `in:"path"`; fields of a
+// nested struct inherit the source of the struct, and fields without one
+// are read from the body. Path, query and header fields are named by
+// their form tag, or else by name mapper, with a header tag taking
+// precedence for headers. The body is decoded according to its
+// Content-Type, the same way Bind does, and never sets fields bound from
+// another source. The struct is validated once all sources are mapped,
+// so the errors of every source are reported together, but no error
+// handling is actually performed here. Errors name path, query and header
+// fields the way they are bound, and body fields by their Go name.
+// An interface pointer can be added as a second argument in order
+// to map the struct to a specific interface.
+func Request(obj interface{}, ifacePtr ...interface{}) macaron.Handler {
+	ensureNotPointer(obj)
+	checkSources(reflect.TypeOf(obj), IN_BODY)
+	checkTags(reflect.TypeOf(obj))
+	namer := requestFieldNamer(reflect.TypeOf(obj))
+
+	return func(ctx *macaron.Context) {
+		var errors Errors
+		b := binderOf(ctx)
+		obj := reflect.New(reflect.TypeOf(obj))
+		bodyErr := false
+		if b.hasSource(obj.Elem().Type(), IN_BODY, IN_BODY) {
+			errors = b.decodeRequestBody(ctx, obj, errors)
+			if bodyErr = errors != nil; bodyErr {
+				// Leave out whatever was decoded from part of the body.
+				obj = reflect.New(obj.Elem().Type())
+			}
+		}
+
+		errors = b.mapSources(obj.Elem(), IN_BODY, ctx, errors)
+		if bodyErr {
+			mapUnvalidated(obj, ctx, errors, ifacePtr...)
+			return
+		}
+		mapValidated(obj, ctx, validate(obj.Interface(), namer), errors, ifacePtr...)
+	}
+}
+
+// decodeRequestBody decodes the request body, if any, into the struct
+// according to its Content-Type.
+func (b *Binder) decodeRequestBody(ctx *macaron.Context, obj reflect.Value, errors Errors) Errors {
+	contentType := ctx.Req.Header.Get("Content-Type")
+	if contentType == "" {
+		return errors
+	}
+	if err := decompressBody(ctx); err != nil {
+		return addReadError(ctx, errors, err)
+	}
+	if d := lookupDecoder(contentType); d != nil {
+		return decodeBody(ctx, d, obj, errors)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		if err := ctx.Req.ParseForm(); err != nil {
+			errors = addReadError(ctx, errors, err)
+		}
+		return b.mapForm(obj, ctx.Req.PostForm, nil, errors)
+	case "multipart/form-data":
+		if err := ctx.Req.ParseMultipartForm(MaxMemory); err != nil {
+			return addReadError(ctx, errors, err)
+		}
+		return b.mapForm(obj, ctx.Req.MultipartForm.Value, ctx.Req.MultipartForm.File, errors)
+	}
+	errors.Add([]string{}, ERR_CONTENT_TYPE, "Unsupported Content-Type")
+	return errors
+}
+
+// hasSource reports whether any field of the struct type is bound from
+// the given source.
+func (b *Binder) hasSource(typ reflect.Type, inherited, source string) bool {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		in := field.Tag.Get("in")
+		if len(in) == 0 {
+			in = inherited
+		}
+		if field.Type.Kind() == reflect.Struct && !b.isTextValue(field.Type) {
+			if b.hasSource(field.Type, in, source) {
+				return true
+			}
+		} else if in == source {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSources panics on an in tag that names no source, so that a typo
+// fails when the middleware is built rather than on every request.
+func checkSources(typ reflect.Type, inherited string) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		in := field.Tag.Get("in")
+		if len(in) == 0 {
+			in = inherited
+		}
+		switch in {
+		case IN_PATH, IN_QUERY, IN_HEADER, IN_BODY:
+		default:
+			panic("binding: unknown source " + in + " of field " + field.Name)
+		}
+		if field.Type.Kind() == reflect.Struct {
+			checkSources(field.Type, in)
+		}
+	}
+}
+
+// sourceKey identifies a field by the path of the struct holding it, as
+// validation gives it, and its Go name.
+type sourceKey struct {
+	path, name string
+}
+
+// requestFieldNamer names the fields of the struct type in validation
+// errors by the name they are bound by from their source: the form name
+// for path and query fields, the header name for header fields. Body
+// fields keep their Go name, as with Bind.
+func requestFieldNamer(typ reflect.Type) fieldNamer {
+	sources := make(map[sourceKey]string)
+	collectSources(typ, "", IN_BODY, sources)
+
+	return func(path string, field reflect.StructField) string {
+		switch sources[sourceKey{path, field.Name}] {
+		case IN_PATH, IN_QUERY:
+			return formName(field)
+		case IN_HEADER:
+			if tag := field.Tag.Get("header"); len(tag) > 0 {
+				return tag
+			}
+			return formName(field)
+		}
+		return field.Name
+	}
+}
+
+// collectSources records the source of every field of the struct type,
+// found at the given path, and of the structs it holds.
+func collectSources(typ reflect.Type, path, inherited string, sources map[sourceKey]string) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		in := field.Tag.Get("in")
+		if len(in) == 0 {
+			in = inherited
+		}
+		sources[sourceKey{path, field.Name}] = in
+		if field.Type.Kind() == reflect.Struct {
+			fieldPath := path
+			if !field.Anonymous {
+				fieldPath = joinFormPath(path, formName(field))
+			}
+			collectSources(field.Type, fieldPath, in, sources)
+		}
+	}
+}
+
+// mapSources sets every field that is not bound from the body from its
+// source, clearing whatever the body may have put there.
+func (b *Binder) mapSources(obj reflect.Value, inherited string, ctx *macaron.Context, errors Errors) Errors {
+	typ := obj.Type()
+	query := ctx.Req.URL.Query()
+
+	for i := 0; i < typ.NumField(); i++ {
+		typeField := typ.Field(i)
+		structField := obj.Field(i)
+
+		in := typeField.Tag.Get("in")
+		if len(in) == 0 {
+			in = inherited
+		}
+		if typeField.Type.Kind() == reflect.Struct && !b.isTextValue(typeField.Type) {
+			errors = b.mapSources(structField, in, ctx, errors)
+			continue
+		}
+		if in == IN_BODY || !structField.CanSet() {
+			continue
+		}
+		structField.Set(reflect.Zero(typeField.Type))
+
+		name := formName(typeField)
+		switch in {
+		case IN_PATH:
+			name = paramName(ctx.AllParams(), typeField)
+			errors = b.setFormValues(structField, typeField.Tag, paramValues(ctx.AllParams(), name, b.isMultiValue(typeField.Type)), name, errors)
+		case IN_QUERY:
+			var mapped bool
+			errors, mapped = b.mapNestedForm(structField, typeField.Tag, name, subForm(query, name), nil, errors)
+			if !mapped {
+				errors = b.setFormValues(structField, typeField.Tag, query[name], name, errors)
+			}
+		case IN_HEADER:
+			if tag := typeField.Tag.Get("header"); len(tag) > 0 {
+				name = tag
+			}
+			errors = b.setFormValues(structField, typeField.Tag, headerValues(ctx.Req.Header, name, b.isMultiValue(typeField.Type)), name, errors)
+		default:
+			panic("binding: unknown source " + in + " of field " + typeField.Name)
+		}
+	}
+	return errors
+}
+
+// headerValues returns the values of a header: the elements of every
+// line when multi is set, or else the lines as they are.
+func headerValues(header http.Header, name string, multi bool) []string {
+	values := header[textproto.CanonicalMIMEHeaderKey(name)]
+	if !multi {
+		return values
+	}
+
+	// Repeated header lines and comma-separated lists are equivalent.
+	var elems []string
+	for _, v := range values {
+		for _, elem := range strings.Split(v, ",") {
+			if elem = strings.TrimSpace(elem); len(elem) > 0 {
+				elems = append(elems, elem)
+			}
+		}
+	}
+	return elems
+}
@@ -0,0 +1,209 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/macaron.v1"
+)
+
+type (
+	Paging struct {
+		Page    int `form:"page"`
+		PerPage int `form:"per_page" binding:"Range(1,100)"`
+	}
+
+	articleRequest struct {
+		Paging  `in:"query"`
+		Id      int               `in:"path" form:"id" binding:"Required"`
+		Tags    []string          `in:"query" form:"tag"`
+		Filter  map[string]string `in:"query" form:"filter"`
+		TraceId string            `in:"header" header:"X-Trace-Id"`
+		Accept  []string          `in:"header"`
+		Title   string            `json:"title" form:"title" binding:"Required"`
+		Content string            `json:"content" form:"content"`
+	}
+)
+
+var requestTestCases = []requestTestCase{
+	{
+		description: "Every source",
+		path:        "/articles/42?page=2&per_page=10&tag=go&tag=web&filter[status]=open",
+		header:      http.Header{"X-Trace-Id": {"t-1"}, "Accept": {"text/html, application/json"}},
+		contentType: _JSON_CONTENT_TYPE,
+		payload:     `{"title": "Glorious Post Title", "content": "Lorem ipsum"}`,
+		expected: articleRequest{
+			Paging:  Paging{Page: 2, PerPage: 10},
+			Id:      42,
+			Tags:    []string{"go", "web"},
+			Filter:  map[string]string{"status": "open"},
+			TraceId: "t-1",
+			Accept:  []string{"text/html", "application/json"},
+			Title:   "Glorious Post Title",
+			Content: "Lorem ipsum",
+		},
+	},
+	{
+		description: "Body cannot set fields of other sources",
+		path:        "/articles/42?per_page=10",
+		contentType: _JSON_CONTENT_TYPE,
+		payload:     `{"title": "Glorious Post Title", "id": 7, "page": 9, "traceid": "t-2"}`,
+		expected: articleRequest{
+			Paging: Paging{PerPage: 10},
+			Id:     42,
+			Title:  "Glorious Post Title",
+		},
+	},
+	{
+		description: "Form body",
+		path:        "/articles/42?per_page=10&title=Ignored",
+		contentType: formContentType,
+		payload:     "title=Glorious+Post+Title&per_page=50",
+		expected: articleRequest{
+			Paging: Paging{PerPage: 10},
+			Id:     42,
+			Title:  "Glorious Post Title",
+		},
+	},
+	{
+		description: "No body",
+		path:        "/articles/42?per_page=10",
+		expected: articleRequest{
+			Paging: Paging{PerPage: 10},
+			Id:     42,
+		},
+		expectedErrors: Errors{
+			{FieldNames: []string{"Title"}, Classification: ERR_REQUIRED, Message: "Required"},
+		},
+	},
+	{
+		description: "Errors of every source together",
+		path:        "/articles/abc?per_page=500",
+		contentType: _JSON_CONTENT_TYPE,
+		payload:     `{"title": 1}`,
+		expected: articleRequest{
+			Paging: Paging{PerPage: 500},
+		},
+		expectedErrors: Errors{
+			{FieldNames: []string{"title"}, Classification: ERR_DESERIALIZATION, Message: "json: cannot unmarshal number into Go struct field articleRequest.title of type string", Line: 1, Column: 11, Offset: 11, Expected: "string"},
+			{FieldNames: []string{"id"}, Classification: ERR_INTERGER_TYPE, Message: "Value could not be parsed as integer"},
+		},
+	},
+	{
+		description: "Errors name fields as their source does",
+		path:        "/articles/abc?per_page=500",
+		contentType: _JSON_CONTENT_TYPE,
+		payload:     `{"title": "Glorious Post Title"}`,
+		expected: articleRequest{
+			Paging: Paging{PerPage: 500},
+			Title:  "Glorious Post Title",
+		},
+		expectedErrors: Errors{
+			{FieldNames: []string{"id"}, Classification: ERR_INTERGER_TYPE, Message: "Value could not be parsed as integer"},
+			{FieldNames: []string{"per_page"}, Classification: ERR_RANGE, Message: "Range"},
+			{FieldNames: []string{"id"}, Classification: ERR_REQUIRED, Message: "Required"},
+		},
+	},
+	{
+		description: "Unsupported Content-Type",
+		path:        "/articles/42?per_page=10",
+		contentType: _CSV_CONTENT_TYPE,
+		payload:     "title\nGlorious Post Title\n",
+		expected: articleRequest{
+			Paging: Paging{PerPage: 10},
+			Id:     42,
+		},
+		expectedErrors: Errors{
+			{FieldNames: []string{}, Classification: ERR_CONTENT_TYPE, Message: "Unsupported Content-Type"},
+		},
+	},
+}
+
+func Test_Request(t *testing.T) {
+	Convey("Test multi-source binding", t, func() {
+		for _, testCase := range requestTestCases {
+			Convey(testCase.description, func() {
+				performRequestTest(testCase)
+			})
+		}
+
+		Convey("Header fields are named by their header", func() {
+			type traced struct {
+				Trace string `in:"header" header:"X-Trace" binding:"Required"`
+			}
+			m := macaron.Classic()
+			m.Get(testRoute, Request(traced{}), func(errs Errors) {
+				So(errs, ShouldResemble, Errors{
+					{FieldNames: []string{"X-Trace"}, Classification: ERR_REQUIRED, Message: "Required"},
+				})
+			})
+			req, err := http.NewRequest("GET", testRoute, nil)
+			So(err, ShouldBeNil)
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+		})
+
+		Convey("Unknown sources fail when the middleware is built", func() {
+			So(func() {
+				Request(struct {
+					Paging `in:"qeury"`
+				}{})
+			}, ShouldPanic)
+		})
+	})
+}
+
+func performRequestTest(testCase requestTestCase) {
+	m := macaron.Classic()
+	m.Post("/articles/:id", Request(articleRequest{}), func(actual articleRequest, errs Errors) {
+		So(actual, ShouldResemble, testCase.expected)
+		if len(testCase.expectedErrors) == 0 {
+			So(errs, ShouldBeEmpty)
+		} else {
+			So(errs, ShouldResemble, testCase.expectedErrors)
+		}
+	})
+
+	req, err := http.NewRequest("POST", testCase.path, strings.NewReader(testCase.payload))
+	So(err, ShouldBeNil)
+	for k, v := range testCase.header {
+		req.Header[k] = v
+	}
+	if testCase.contentType != "" {
+		req.Header.Set("Content-Type", testCase.contentType)
+	}
+
+	resp := httptest.NewRecorder()
+	m.ServeHTTP(resp, req)
+	So(resp.Code, ShouldEqual, http.StatusOK)
+}
+
+type (
+	requestTestCase struct {
+		description    string
+		path           string
+		header         http.Header
+		contentType    string
+		payload        string
+		expected       articleRequest
+		expectedErrors Errors
+	}
+)
@@ -0,0 +1,318 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// jsonDecoder decodes JSON payloads, strictly if the Binder or the model
+// asks for it.
+type jsonDecoder struct {
+	strict bool
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+func (d jsonDecoder) Decode(r io.Reader, v interface{}) error {
+	if d.strict {
+		return decodeStrictJson(r, v)
+	}
+
+	w := newJsonWindow(r)
+	err := json.NewDecoder(w).Decode(v)
+	return jsonError(err, w, v)
+}
+
+func (jsonDecoder) configure(b *Binder, obj interface{}) Decoder {
+	if model, ok := obj.(StrictJsonModel); ok {
+		return jsonDecoder{strict: model.StrictJson()}
+	}
+	return jsonDecoder{strict: b.isStrictJson()}
+}
+
+// decodeStrictJson decodes the payload like json.Decoder does, then
+// reports unknown fields and duplicate keys and data after the value.
+func decodeStrictJson(r io.Reader, v interface{}) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err = dec.Decode(v); err != nil {
+		return jsonError(err, &jsonWindow{buf: data, newline: -1}, v)
+	}
+
+	errs := checkJson(json.NewDecoder(bytes.NewReader(data)), reflect.TypeOf(v), "", nil)
+	offset := dec.InputOffset()
+	if _, err = dec.Token(); err != io.EOF {
+		errs.Add([]string{}, ERR_TRAILING_DATA, fmt.Sprintf("Unexpected data after the value at offset %d", offset))
+	}
+	if len(errs) > 0 {
+		return decodeErrors(errs)
+	}
+	return nil
+}
+
+// yamlDecoder decodes YAML payloads, strictly if the Binder or the model
+// asks for it, and within the limits of the Binder.
+type yamlDecoder struct {
+	strict bool
+	limits YamlLimits
+}
+
+func (d yamlDecoder) Decode(r io.Reader, v interface{}) error {
+	if d.limits.MaxBytes > 0 {
+		r = io.LimitReader(r, d.limits.MaxBytes+1)
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if d.limits.MaxBytes > 0 && int64(len(data)) > d.limits.MaxBytes {
+		return Error{
+			FieldNames:     []string{},
+			Classification: ERR_LIMIT_EXCEEDED,
+			Message:        fmt.Sprintf("Document is larger than %d bytes", d.limits.MaxBytes),
+		}
+	}
+
+	var node yaml.Node
+	if err = yaml.Unmarshal(data, &node); err != nil {
+		return yamlSyntaxError(err)
+	}
+	if node.Kind == 0 {
+		return io.EOF
+	}
+	if err = checkYamlLimits(&node, d.limits, 0, new(int)); err != nil {
+		return err
+	}
+
+	if d.strict {
+		// Known fields are only checked when decoding a document itself.
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(v)
+	} else {
+		err = node.Decode(v)
+	}
+	if err != nil {
+		return yamlError(err, &node, v, d.strict)
+	}
+	markYamlNulls(&node, reflect.ValueOf(v))
+	return nil
+}
+
+func (yamlDecoder) configure(b *Binder, obj interface{}) Decoder {
+	d := yamlDecoder{strict: b.isStrictYaml(), limits: b.getYamlLimits()}
+	if model, ok := obj.(StrictYamlModel); ok {
+		d.strict = model.StrictYaml()
+	}
+	return d
+}
+
+// checkYamlLimits checks that the node stays within the limits on aliases
+// and depth, at the given depth and with the given number of aliases
+// expanded so far.
+func checkYamlLimits(n *yaml.Node, limits YamlLimits, depth int, aliases *int) error {
+	if limits.MaxAliases == 0 && limits.MaxDepth == 0 {
+		return nil
+	}
+
+	switch n.Kind {
+	case yaml.AliasNode:
+		if limits.MaxAliases == 0 {
+			return nil
+		}
+		if *aliases++; *aliases > limits.MaxAliases {
+			return Error{
+				FieldNames:     []string{},
+				Classification: ERR_LIMIT_EXCEEDED,
+				Message:        fmt.Sprintf("Document expands to more than %d aliases", limits.MaxAliases),
+				Line:           n.Line,
+				Column:         n.Column,
+			}
+		}
+		return checkYamlLimits(n.Alias, limits, depth, aliases)
+	case yaml.MappingNode, yaml.SequenceNode:
+		if depth++; limits.MaxDepth > 0 && depth > limits.MaxDepth {
+			return Error{
+				FieldNames:     []string{},
+				Classification: ERR_LIMIT_EXCEEDED,
+				Message:        fmt.Sprintf("Document is nested deeper than %d levels", limits.MaxDepth),
+				Line:           n.Line,
+				Column:         n.Column,
+			}
+		}
+	}
+	for _, c := range n.Content {
+		if err := checkYamlLimits(c, limits, depth, aliases); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkJson reads the next JSON value from the decoder and reports the
+// keys of its objects that match no field of the struct they are decoded
+// into, or that repeat a key, naming them by their path. A nil type stands
+// for a value that is not decoded field by field.
+func checkJson(dec *json.Decoder, typ reflect.Type, path string, errs Errors) Errors {
+	tok, err := dec.Token()
+	if err != nil {
+		return errs
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return errs
+	}
+
+	typ = jsonTarget(typ)
+	switch delim {
+	case '[':
+		var elemType reflect.Type
+		if typ != nil && (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) {
+			elemType = typ.Elem()
+		}
+		for i := 0; dec.More(); i++ {
+			errs = checkJson(dec, elemType, fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case '{':
+		var fields map[string]reflect.Type
+		var elemType reflect.Type
+		if typ != nil && typ.Kind() == reflect.Struct {
+			fields = make(map[string]reflect.Type)
+			jsonFields(typ, fields)
+		} else if typ != nil && typ.Kind() == reflect.Map {
+			elemType = typ.Elem()
+		}
+
+		seen := make(map[string]bool)
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return errs
+			}
+			key, _ := tok.(string)
+			keyPath := joinFormPath(path, key)
+
+			name, valueType := key, elemType
+			if fields != nil {
+				if name, valueType, ok = lookupJsonField(fields, key); !ok {
+					errs.Add([]string{keyPath}, ERR_UNKNOWN_FIELD, "Unknown field")
+					errs = checkJson(dec, nil, keyPath, errs)
+					continue
+				}
+			}
+			if seen[name] {
+				errs.Add([]string{keyPath}, ERR_DUPLICATE_KEY, "Duplicate key")
+			}
+			seen[name] = true
+			errs = checkJson(dec, valueType, keyPath, errs)
+		}
+	}
+
+	// Consume the closing delimiter.
+	_, _ = dec.Token()
+	return errs
+}
+
+// jsonTarget returns the type a JSON value is decoded into field by field
+// or element by element for a value of the given type, or nil if there is
+// none, e.g. for interfaces and types implementing json.Unmarshaler.
+func jsonTarget(typ reflect.Type) reflect.Type {
+	for typ != nil {
+		switch {
+		case typ.Kind() == reflect.Ptr:
+			typ = typ.Elem()
+		case typ.Implements(optionalType):
+			field, _ := typ.FieldByName("Value")
+			typ = field.Type
+		case typ.Kind() == reflect.Interface || reflect.PtrTo(typ).Implements(jsonUnmarshalerType):
+			return nil
+		default:
+			return typ
+		}
+	}
+	return nil
+}
+
+// jsonFields collects the fields of the struct by the key encoding/json
+// decodes them from, those of embedded structs included.
+func jsonFields(typ reflect.Type, fields map[string]reflect.Type) {
+	var embedded []reflect.Type
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name := field.Tag.Get("json")
+		if i := strings.IndexByte(name, ','); i >= 0 {
+			name = name[:i]
+		}
+		if field.Tag.Get("json") == "-" {
+			continue
+		}
+
+		if field.Anonymous && len(name) == 0 {
+			t := field.Type
+			if t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			if t.Kind() == reflect.Struct {
+				embedded = append(embedded, t)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if len(name) == 0 {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+
+	// Fields of embedded structs do not shadow those of the outer one.
+	for _, t := range embedded {
+		inner := make(map[string]reflect.Type)
+		jsonFields(t, inner)
+		for name, fieldType := range inner {
+			if _, ok := fields[name]; !ok {
+				fields[name] = fieldType
+			}
+		}
+	}
+}
+
+// lookupJsonField returns the field a key is decoded into, preferring an
+// exact match over a case-insensitive one like encoding/json does.
+func lookupJsonField(fields map[string]reflect.Type, key string) (string, reflect.Type, bool) {
+	if fieldType, ok := fields[key]; ok {
+		return key, fieldType, true
+	}
+	for name, fieldType := range fields {
+		if strings.EqualFold(name, key) {
+			return name, fieldType, true
+		}
+	}
+	return "", nil, false
+}
@@ -0,0 +1,178 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/macaron.v1"
+)
+
+type (
+	audit struct {
+		By string `json:"by"`
+	}
+
+	strictOrder struct {
+		Id       int                    `json:"id"`
+		Customer Person                 `json:"customer"`
+		Items    []LineItem             `json:"items"`
+		Meta     map[string]interface{} `json:"meta"`
+		audit
+	}
+
+	strictNote struct {
+		Text string `json:"text"`
+	}
+
+	laxNote struct {
+		Text string `json:"text" yaml:"text"`
+	}
+
+	yamlOrder struct {
+		Id       int        `yaml:"id"`
+		Customer Person     `yaml:"customer"`
+		Items    []LineItem `yaml:"items"`
+	}
+
+	yamlTree struct {
+		A interface{} `yaml:"a"`
+		B interface{} `yaml:"b"`
+		C interface{} `yaml:"c"`
+	}
+)
+
+func (strictNote) StrictJson() bool { return true }
+
+func (laxNote) StrictJson() bool { return false }
+
+func (laxNote) StrictYaml() bool { return false }
+
+func Test_StrictJson(t *testing.T) {
+	Convey("Test strict JSON decoding", t, func() {
+		strict := NewBinder()
+		strict.SetStrictJson(true)
+
+		performStrictJsonTest := func(b *Binder, model interface{}, payload string, expectedErrors Errors) {
+			m := macaron.Classic()
+			if b != nil {
+				m.Use(b.Handler())
+			}
+			m.Post(testRoute, Json(model), func(errs Errors) {
+				if len(expectedErrors) == 0 {
+					So(errs, ShouldBeEmpty)
+				} else {
+					So(errs, ShouldResemble, expectedErrors)
+				}
+			})
+
+			req, err := http.NewRequest("POST", testRoute, strings.NewReader(payload))
+			So(err, ShouldBeNil)
+			req.Header.Set("Content-Type", _JSON_CONTENT_TYPE)
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+		}
+
+		payload := `{"id": 1, "customer": {"name": "Ann", "nick": "a"},
+			"items": [{"sku": "a"}, {"Sku": "b", "sku": "c", "qty": 1}],
+			"meta": {"x": 1, "x": 2}, "by": "me", "extra": true} []`
+
+		Convey("Strict binder", func() {
+			performStrictJsonTest(strict, strictOrder{}, payload, Errors{
+				{FieldNames: []string{"customer.nick"}, Classification: ERR_UNKNOWN_FIELD, Message: "Unknown field"},
+				{FieldNames: []string{"items[1].sku"}, Classification: ERR_DUPLICATE_KEY, Message: "Duplicate key"},
+				{FieldNames: []string{"items[1].qty"}, Classification: ERR_UNKNOWN_FIELD, Message: "Unknown field"},
+				{FieldNames: []string{"meta.x"}, Classification: ERR_DUPLICATE_KEY, Message: "Duplicate key"},
+				{FieldNames: []string{"extra"}, Classification: ERR_UNKNOWN_FIELD, Message: "Unknown field"},
+				{FieldNames: []string{}, Classification: ERR_TRAILING_DATA, Message: "Unexpected data after the value at offset 171"},
+			})
+			performStrictJsonTest(strict, strictOrder{}, `{"id": 1, "customer": {"name": "Ann"}, "by": "me"}`, nil)
+		})
+
+		Convey("Lenient by default", func() {
+			performStrictJsonTest(nil, strictOrder{}, payload, nil)
+		})
+
+		Convey("Models decide for themselves", func() {
+			performStrictJsonTest(nil, strictNote{}, `{"text": "a", "txt": "b"}`, Errors{
+				{FieldNames: []string{"txt"}, Classification: ERR_UNKNOWN_FIELD, Message: "Unknown field"},
+			})
+			performStrictJsonTest(strict, laxNote{}, `{"text": "a", "txt": "b"}`, nil)
+		})
+	})
+}
+
+func Test_StrictYaml(t *testing.T) {
+	Convey("Test strict and limited YAML decoding", t, func() {
+		performStrictYamlTest := func(b *Binder, model interface{}, payload string, expectedErrors Errors) {
+			m := macaron.Classic()
+			if b != nil {
+				m.Use(b.Handler())
+			}
+			m.Post(testRoute, Yaml(model), func(errs Errors) {
+				if len(expectedErrors) == 0 {
+					So(errs, ShouldBeEmpty)
+				} else {
+					So(errs, ShouldResemble, expectedErrors)
+				}
+			})
+
+			req, err := http.NewRequest("POST", testRoute, strings.NewReader(payload))
+			So(err, ShouldBeNil)
+			req.Header.Set("Content-Type", _YAML_CONTENT_TYPE)
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+		}
+
+		strict := NewBinder()
+		strict.SetStrictYaml(true)
+		payload := "id: 1\ncustomer:\n  name: Ann\n  nick: a\nitems:\n  - sku: a\n    qty: 2\nextra: true"
+
+		Convey("Strict binder", func() {
+			performStrictYamlTest(strict, yamlOrder{}, payload, Errors{
+				{FieldNames: []string{"customer.nick"}, Classification: ERR_UNKNOWN_FIELD, Message: "line 4: field nick not found in type binding.Person", Line: 4, Column: 3},
+				{FieldNames: []string{"items[0].qty"}, Classification: ERR_UNKNOWN_FIELD, Message: "line 7: field qty not found in type binding.LineItem", Line: 7, Column: 5},
+				{FieldNames: []string{"extra"}, Classification: ERR_UNKNOWN_FIELD, Message: "line 8: field extra not found in type binding.yamlOrder", Line: 8, Column: 1},
+			})
+			performStrictYamlTest(strict, laxNote{}, "text: a\ntxt: b", nil)
+		})
+
+		Convey("Lenient by default", func() {
+			performStrictYamlTest(nil, yamlOrder{}, payload, nil)
+		})
+
+		Convey("Limits", func() {
+			limited := NewBinder()
+			limited.SetYamlLimits(YamlLimits{MaxBytes: 64, MaxAliases: 5, MaxDepth: 4})
+
+			performStrictYamlTest(limited, yamlTree{}, "a: &a [x, x]\nb: &b [*a, *a]\nc: [*b]", nil)
+			performStrictYamlTest(limited, yamlTree{}, "a: &a [x, x]\nb: &b [*a, *a]\nc: [*b, *b]", Errors{
+				{FieldNames: []string{}, Classification: ERR_LIMIT_EXCEEDED, Message: "Document expands to more than 5 aliases", Line: 3, Column: 9},
+			})
+			performStrictYamlTest(limited, yamlTree{}, "a: [[[[x]]]]", Errors{
+				{FieldNames: []string{}, Classification: ERR_LIMIT_EXCEEDED, Message: "Document is nested deeper than 4 levels", Line: 1, Column: 7},
+			})
+			performStrictYamlTest(limited, yamlTree{}, "a: "+strings.Repeat("x", 64), Errors{
+				{FieldNames: []string{}, Classification: ERR_LIMIT_EXCEEDED, Message: "Document is larger than 64 bytes"},
+			})
+		})
+	})
+}
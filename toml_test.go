@@ -0,0 +1,136 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/macaron.v1"
+)
+
+var tomlTestCases = []tomlTestCase{
+	{
+		description: "Happy path",
+		payload: `title = "Glorious Post Title"
+content = "Lorem ipsum dolor sit amet"`,
+		expected: Post{Title: "Glorious Post Title", Content: "Lorem ipsum dolor sit amet"},
+	},
+	{
+		description: "Empty payload",
+		payload:     ``,
+		expected:    Post{},
+		expectedErrors: Errors{
+			{FieldNames: []string{"Title"}, Classification: ERR_REQUIRED, Message: "Required"},
+		},
+	},
+	{
+		description: "Malformed TOML",
+		payload: `title = "Glorious Post Title"
+content = = "Lorem ipsum"`,
+		expected: Post{},
+		expectedErrors: Errors{
+			{FieldNames: []string{}, Classification: ERR_DESERIALIZATION, Message: "toml: incomplete number", Line: 2, Column: 11},
+		},
+	},
+	{
+		description: "Type mismatch",
+		payload: `title = "Glorious Post Title"
+Id = "one"`,
+		expected: BlogPost{},
+		expectedErrors: Errors{
+			{FieldNames: []string{}, Classification: ERR_DESERIALIZATION, Message: "toml: cannot decode TOML string into struct field binding.BlogPost.Id of type int", Line: 2, Column: 6},
+		},
+	},
+	{
+		description: "Nested and embedded struct",
+		payload: `title = "Glorious Post Title"
+Id = 1
+ratings = [4, 3]
+
+[author]
+name = "Matt Holt"`,
+		expected: BlogPost{Post: Post{Title: "Glorious Post Title"}, Id: 1, Ratings: []int{4, 3}, Author: Person{Name: "Matt Holt"}},
+	},
+	{
+		description: "Required nested struct field not specified",
+		payload: `title = "Glorious Post Title"
+Id = 1
+
+[author]
+email = "matt@example.com"`,
+		expected: BlogPost{Post: Post{Title: "Glorious Post Title"}, Id: 1, Author: Person{Email: "matt@example.com"}},
+		expectedErrors: Errors{
+			{FieldNames: []string{"Name"}, Classification: ERR_REQUIRED, Message: "Required"},
+		},
+	},
+}
+
+func Test_Toml(t *testing.T) {
+	Convey("Test TOML", t, func() {
+		for _, testCase := range tomlTestCases {
+			Convey(testCase.description, func() {
+				performTomlTest(Toml, testCase)
+				performTomlTest(BindIgnErr, testCase)
+			})
+		}
+	})
+}
+
+func performTomlTest(binder handlerFunc, testCase tomlTestCase) {
+	m := macaron.Classic()
+
+	tomlTestHandler := func(actual interface{}, errs Errors) {
+		So(actual, ShouldResemble, testCase.expected)
+		if len(testCase.expectedErrors) == 0 {
+			So(errs, ShouldBeEmpty)
+		} else {
+			// Validation errors may follow the ones under test.
+			So(len(errs), ShouldBeGreaterThanOrEqualTo, len(testCase.expectedErrors))
+			So(errs[:len(testCase.expectedErrors)], ShouldResemble, testCase.expectedErrors)
+		}
+	}
+
+	switch testCase.expected.(type) {
+	case Post:
+		m.Post(testRoute, binder(Post{}), func(actual Post, errs Errors) {
+			tomlTestHandler(actual, errs)
+		})
+	case BlogPost:
+		m.Post(testRoute, binder(BlogPost{}), func(actual BlogPost, errs Errors) {
+			tomlTestHandler(actual, errs)
+		})
+	}
+
+	req, err := http.NewRequest("POST", testRoute, strings.NewReader(testCase.payload))
+	So(err, ShouldBeNil)
+	req.Header.Set("Content-Type", _TOML_CONTENT_TYPE)
+
+	resp := httptest.NewRecorder()
+	m.ServeHTTP(resp, req)
+	So(resp.Code, ShouldEqual, http.StatusOK)
+}
+
+type (
+	tomlTestCase struct {
+		description    string
+		payload        string
+		expected       interface{}
+		expectedErrors Errors
+	}
+)
@@ -0,0 +1,121 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/macaron.v1"
+)
+
+type (
+	Owner struct {
+		UserId int `form:"uid" binding:"Required"`
+	}
+
+	fileParams struct {
+		*Owner
+		Kind     string   `binding:"In(docs,images)"`
+		Segments []string `form:"*"`
+		Path     string   `form:"*"`
+		Ignored  string   `form:"-"`
+	}
+)
+
+var urlTestCases = []urlTestCase{
+	{
+		description: "Named and glob parameters",
+		path:        "/users/42/docs/reports/2021/q1.pdf",
+		expected: fileParams{
+			Owner:    &Owner{UserId: 42},
+			Kind:     "docs",
+			Segments: []string{"reports", "2021", "q1.pdf"},
+			Path:     "reports/2021/q1.pdf",
+		},
+	},
+	{
+		description: "Conversion and validation errors",
+		path:        "/users/abc/videos/a",
+		expected: fileParams{
+			Kind:     "videos",
+			Segments: []string{"a"},
+			Path:     "a",
+		},
+		expectedErrors: Errors{
+			{FieldNames: []string{"uid"}, Classification: ERR_INTERGER_TYPE, Message: "Value could not be parsed as integer"},
+			{FieldNames: []string{"kind"}, Classification: ERR_IN, Message: "In"},
+		},
+	},
+}
+
+func Test_URL(t *testing.T) {
+	Convey("Test URL parameter binding", t, func() {
+		for _, testCase := range urlTestCases {
+			Convey(testCase.description, func() {
+				performURLTest(testCase)
+			})
+		}
+
+		Convey("Parameters named after fields", func() {
+			type postParams struct {
+				Id   int
+				Slug string `form:"slug"`
+			}
+
+			m := macaron.Classic()
+			m.Get("/posts/:Id/:Slug", URL(postParams{}), func(actual postParams, errs Errors) {
+				So(errs, ShouldBeEmpty)
+				So(actual, ShouldResemble, postParams{Id: 42, Slug: "hello"})
+			})
+
+			req, err := http.NewRequest("GET", "/posts/42/hello", nil)
+			So(err, ShouldBeNil)
+			resp := httptest.NewRecorder()
+			m.ServeHTTP(resp, req)
+			So(resp.Code, ShouldEqual, http.StatusOK)
+		})
+	})
+}
+
+func performURLTest(testCase urlTestCase) {
+	m := macaron.Classic()
+	m.Get("/users/:uid/:kind/*", URL(fileParams{}), func(actual fileParams, errs Errors) {
+		So(actual, ShouldResemble, testCase.expected)
+		if len(testCase.expectedErrors) == 0 {
+			So(errs, ShouldBeEmpty)
+		} else {
+			So(errs, ShouldResemble, testCase.expectedErrors)
+		}
+	})
+
+	req, err := http.NewRequest("GET", testCase.path, nil)
+	So(err, ShouldBeNil)
+
+	resp := httptest.NewRecorder()
+	m.ServeHTTP(resp, req)
+	So(resp.Code, ShouldEqual, http.StatusOK)
+}
+
+type (
+	urlTestCase struct {
+		description    string
+		path           string
+		expected       fileParams
+		expectedErrors Errors
+	}
+)
@@ -390,6 +390,39 @@ var validationTestCases = []validationTestCase{
 		},
 		expectedErrors: Errors{},
 	},
+	{
+		description: "Arrays and interfaces of structs",
+		data: Team{
+			Leads:   [2]Person{{Name: "Ann"}, {}},
+			Mascot:  &Person{},
+			Members: []interface{}{Person{Name: "Bob"}, "Carl", &Person{}},
+		},
+		expectedErrors: Errors{
+			Error{
+				FieldNames:     []string{"Name"},
+				Classification: ERR_REQUIRED,
+				Message:        "Required",
+			},
+			Error{
+				FieldNames:     []string{"Name"},
+				Classification: ERR_REQUIRED,
+				Message:        "Required",
+			},
+			Error{
+				FieldNames:     []string{"Name"},
+				Classification: ERR_REQUIRED,
+				Message:        "Required",
+			},
+		},
+	},
+	{
+		description: "Nil interfaces",
+		data: Team{
+			Leads:   [2]Person{{Name: "Ann"}, {Name: "Bob"}},
+			Members: []interface{}{nil},
+		},
+		expectedErrors: Errors{},
+	},
 }
 
 func Test_Validation(t *testing.T) {
@@ -400,6 +433,33 @@ func Test_Validation(t *testing.T) {
 	})
 }
 
+type nickPatch struct {
+	Nick *string `binding:"NotNilPtr"`
+}
+
+func Test_CustomRulesOnPointers(t *testing.T) {
+	Convey("Custom rules get pointer fields as they are", t, func() {
+		AddRule(&Rule{
+			func(rule string) bool {
+				return rule == "NotNilPtr"
+			},
+			func(errs Errors, name string, v interface{}) (bool, Errors) {
+				if p, ok := v.(*string); !ok || p == nil {
+					errs.Add([]string{name}, "NilError", "NotNilPtr")
+					return false, errs
+				}
+				return true, errs
+			},
+		})
+
+		nick := "jo"
+		So(RawValidate(nickPatch{}), ShouldResemble, Errors{
+			{FieldNames: []string{"Nick"}, Classification: "NilError", Message: "NotNilPtr"},
+		})
+		So(RawValidate(nickPatch{Nick: &nick}), ShouldBeEmpty)
+	})
+}
+
 func performValidationTest(t *testing.T, testCase validationTestCase) {
 	httpRecorder := httptest.NewRecorder()
 	m := macaron.Classic()
@@ -0,0 +1,224 @@
+// Copyright 2021 The Macaron Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package binding
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/macaron.v1"
+)
+
+var xmlTestCases = []xmlTestCase{
+	{
+		description:        "Happy path",
+		shouldSucceedOnXml: true,
+		payload:            `<post><title>Glorious Post Title</title><content>Lorem ipsum dolor sit amet</content></post>`,
+		contentType:        _XML_CONTENT_TYPE,
+		expected:           Post{Title: "Glorious Post Title", Content: "Lorem ipsum dolor sit amet"},
+	},
+	{
+		description:        "Happy path with interface",
+		shouldSucceedOnXml: true,
+		withInterface:      true,
+		payload:            `<post><title>Glorious Post Title</title><content>Lorem ipsum dolor sit amet</content></post>`,
+		contentType:        _XML_CONTENT_TYPE,
+		expected:           Post{Title: "Glorious Post Title", Content: "Lorem ipsum dolor sit amet"},
+	},
+	{
+		description:        "Text/xml content type",
+		shouldSucceedOnXml: true,
+		payload:            `<?xml version="1.0"?><post><title>Glorious Post Title</title></post>`,
+		contentType:        "text/xml",
+		expected:           Post{Title: "Glorious Post Title"},
+	},
+	{
+		description:        "Nil payload",
+		shouldSucceedOnXml: false,
+		payload:            `-nil-`,
+		contentType:        _XML_CONTENT_TYPE,
+		expected:           Post{},
+	},
+	{
+		description:        "Empty payload",
+		shouldSucceedOnXml: false,
+		payload:            ``,
+		contentType:        _XML_CONTENT_TYPE,
+		expected:           Post{},
+	},
+	{
+		description:        "Empty content type",
+		shouldSucceedOnXml: true,
+		shouldFailOnBind:   true,
+		payload:            `<post><title>Glorious Post Title</title><content>Lorem ipsum dolor sit amet</content></post>`,
+		contentType:        ``,
+		expected:           Post{Title: "Glorious Post Title", Content: "Lorem ipsum dolor sit amet"},
+	},
+	{
+		description:        "Unsupported content type",
+		shouldSucceedOnXml: true,
+		shouldFailOnBind:   true,
+		payload:            `<post><title>Glorious Post Title</title><content>Lorem ipsum dolor sit amet</content></post>`,
+		contentType:        `BoGuS`,
+		expected:           Post{Title: "Glorious Post Title", Content: "Lorem ipsum dolor sit amet"},
+	},
+	{
+		description:        "Malformed XML",
+		shouldSucceedOnXml: false,
+		payload:            `<post><title>foo</post>`,
+		contentType:        _XML_CONTENT_TYPE,
+		expected:           Post{},
+	},
+	{
+		description:        "Deserialization with nested and embedded struct",
+		shouldSucceedOnXml: true,
+		payload:            `<blog><title>Glorious Post Title</title><Id>1</Id><author><name>Matt Holt</name></author></blog>`,
+		contentType:        _XML_CONTENT_TYPE,
+		expected:           BlogPost{Post: Post{Title: "Glorious Post Title"}, Id: 1, Author: Person{Name: "Matt Holt"}},
+	},
+	{
+		description:        "Deserialization with nested and embedded struct with interface",
+		shouldSucceedOnXml: true,
+		withInterface:      true,
+		payload:            `<blog><title>Glorious Post Title</title><Id>1</Id><author><name>Matt Holt</name></author></blog>`,
+		contentType:        _XML_CONTENT_TYPE,
+		expected:           BlogPost{Post: Post{Title: "Glorious Post Title"}, Id: 1, Author: Person{Name: "Matt Holt"}},
+	},
+	{
+		description:        "Required nested struct field not specified",
+		shouldSucceedOnXml: false,
+		payload:            `<blog><title>Glorious Post Title</title><Id>1</Id><author></author></blog>`,
+		contentType:        _XML_CONTENT_TYPE,
+		expected:           BlogPost{Post: Post{Title: "Glorious Post Title"}, Id: 1},
+	},
+	{
+		description:        "Required embedded struct field not specified",
+		shouldSucceedOnXml: false,
+		payload:            `<blog><Id>1</Id><author><name>Matt Holt</name></author></blog>`,
+		contentType:        _XML_CONTENT_TYPE,
+		expected:           BlogPost{Id: 1, Author: Person{Name: "Matt Holt"}},
+	},
+	{
+		description:        "Repeated elements into slice",
+		shouldSucceedOnXml: true,
+		payload:            `<blog><title>Glorious Post Title</title><Id>1</Id><ratings>4</ratings><ratings>3</ratings><author><name>Matt Holt</name></author></blog>`,
+		contentType:        _XML_CONTENT_TYPE,
+		expected:           BlogPost{Post: Post{Title: "Glorious Post Title"}, Id: 1, Ratings: []int{4, 3}, Author: Person{Name: "Matt Holt"}},
+	},
+	{
+		description:        "Slice of structs",
+		shouldSucceedOnXml: true,
+		payload:            `<group><name>group1</name><people><name>awoods</name></people><people><name>anthony</name></people></group>`,
+		contentType:        _XML_CONTENT_TYPE,
+		expected:           Group{Name: "group1", People: []Person{Person{Name: "awoods"}, Person{Name: "anthony"}}},
+	},
+}
+
+func Test_Xml(t *testing.T) {
+	Convey("Test XML", t, func() {
+		for _, testCase := range xmlTestCases {
+			performXmlTest(t, Xml, testCase)
+		}
+	})
+}
+
+func performXmlTest(t *testing.T, binder handlerFunc, testCase xmlTestCase) {
+	var payload io.Reader
+	httpRecorder := httptest.NewRecorder()
+	m := macaron.Classic()
+
+	xmlTestHandler := func(actual interface{}, errs Errors) {
+		if testCase.shouldSucceedOnXml && len(errs) > 0 {
+			So(len(errs), ShouldEqual, 0)
+		} else if !testCase.shouldSucceedOnXml && len(errs) == 0 {
+			So(len(errs), ShouldNotEqual, 0)
+		}
+		So(fmt.Sprintf("%+v", actual), ShouldEqual, fmt.Sprintf("%+v", testCase.expected))
+	}
+
+	switch testCase.expected.(type) {
+	case Post:
+		if testCase.withInterface {
+			m.Post(testRoute, binder(Post{}, (*modeler)(nil)), func(actual Post, iface modeler, errs Errors) {
+				So(actual.Title, ShouldEqual, iface.Model())
+				xmlTestHandler(actual, errs)
+			})
+		} else {
+			m.Post(testRoute, binder(Post{}), func(actual Post, errs Errors) {
+				xmlTestHandler(actual, errs)
+			})
+		}
+
+	case BlogPost:
+		if testCase.withInterface {
+			m.Post(testRoute, binder(BlogPost{}, (*modeler)(nil)), func(actual BlogPost, iface modeler, errs Errors) {
+				So(actual.Title, ShouldEqual, iface.Model())
+				xmlTestHandler(actual, errs)
+			})
+		} else {
+			m.Post(testRoute, binder(BlogPost{}), func(actual BlogPost, errs Errors) {
+				xmlTestHandler(actual, errs)
+			})
+		}
+	case Group:
+		m.Post(testRoute, binder(Group{}), func(actual Group, errs Errors) {
+			xmlTestHandler(actual, errs)
+		})
+	}
+
+	if testCase.payload == "-nil-" {
+		payload = nil
+	} else {
+		payload = strings.NewReader(testCase.payload)
+	}
+
+	req, err := http.NewRequest("POST", testRoute, payload)
+	if err != nil {
+		panic(err)
+	}
+	req.Header.Set("Content-Type", testCase.contentType)
+
+	m.ServeHTTP(httpRecorder, req)
+
+	switch httpRecorder.Code {
+	case http.StatusNotFound:
+		panic("Routing is messed up in test fixture (got 404): check method and path")
+	case http.StatusInternalServerError:
+		panic("Something bad happened on '" + testCase.description + "'")
+	default:
+		if testCase.shouldSucceedOnXml &&
+			httpRecorder.Code != http.StatusOK &&
+			!testCase.shouldFailOnBind {
+			So(httpRecorder.Code, ShouldEqual, http.StatusOK)
+		}
+	}
+}
+
+type (
+	xmlTestCase struct {
+		description        string
+		withInterface      bool
+		shouldSucceedOnXml bool
+		shouldFailOnBind   bool
+		payload            string
+		contentType        string
+		expected           interface{}
+	}
+)